@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/backup"
+	"text/tabwriter"
+	"time"
+)
+
+var backupCommands = map[string]func(args []string) error{
+	"create":  runBackupCreate,
+	"list":    runBackupList,
+	"rotate":  runBackupRotate,
+	"restore": runBackupRestore,
+}
+
+func backupUsage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  revision backup create [-keep N] [-max-age D] savedir backupdir")
+	fmt.Fprintln(os.Stderr, "  revision backup list [-json] backupdir")
+	fmt.Fprintln(os.Stderr, "  revision backup rotate [-keep N] [-max-age D] backupdir")
+	fmt.Fprintln(os.Stderr, "  revision backup restore [-backups backupdir] snapshot.zip savedir")
+}
+
+func runBackup(args []string) error {
+	if len(args) == 0 {
+		backupUsage()
+		return fmt.Errorf("expected a backup command")
+	}
+
+	command, ok := backupCommands[args[0]]
+	if !ok {
+		backupUsage()
+		return fmt.Errorf("unknown backup command %q", args[0])
+	}
+
+	return command(args[1:])
+}
+
+func addRotationFlags(flags *flag.FlagSet) (*int, *time.Duration) {
+	keep := flags.Int("keep", 0, "keep only the newest N snapshots (0: no limit)")
+	maxAge := flags.Duration("max-age", 0, "remove snapshots older than this, e.g. 720h (0: no limit)")
+	return keep, maxAge
+}
+
+func rotate(backupDir string, keep int, maxAge time.Duration) error {
+	if keep == 0 && maxAge == 0 {
+		return nil
+	}
+
+	removed, err := backup.Rotate(backupDir, keep, maxAge)
+	for _, snapshot := range removed {
+		fmt.Printf("removed %s\n", snapshot.Path)
+	}
+	return err
+}
+
+func runBackupCreate(args []string) error {
+	flags := flag.NewFlagSet("backup create", flag.ExitOnError)
+	keep, maxAge := addRotationFlags(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		backupUsage()
+		return fmt.Errorf("expected a save directory and a backup directory")
+	}
+	saveDir := flags.Arg(0)
+	backupDir := flags.Arg(1)
+
+	snapshot, created, err := backup.Create(saveDir, backupDir)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Printf("created %s\n", snapshot.Path)
+	} else {
+		fmt.Printf("unchanged since %s\n", snapshot.Path)
+	}
+
+	return rotate(backupDir, *keep, *maxAge)
+}
+
+func runBackupList(args []string) error {
+	flags := flag.NewFlagSet("backup list", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print snapshots as JSON")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		backupUsage()
+		return fmt.Errorf("expected a backup directory")
+	}
+
+	snapshots, err := backup.List(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshots)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tCREATED\tFILES\tCHARACTERS\tSLOTS\tBUILD\tERRORS")
+	for _, snapshot := range snapshots {
+		manifest := snapshot.Manifest
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%v\t%d\t%d\n",
+			filepath.Base(snapshot.Path), manifest.Created.Local().Format("2006-01-02 15:04:05"), len(manifest.Files),
+			manifest.Summary.Characters, manifest.Summary.Slots, manifest.Summary.BuildNumber, len(manifest.Summary.Errors))
+	}
+	return w.Flush()
+}
+
+func runBackupRotate(args []string) error {
+	flags := flag.NewFlagSet("backup rotate", flag.ExitOnError)
+	keep, maxAge := addRotationFlags(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		backupUsage()
+		return fmt.Errorf("expected a backup directory")
+	}
+	if *keep == 0 && *maxAge == 0 {
+		return fmt.Errorf("expected -keep or -max-age")
+	}
+
+	return rotate(flags.Arg(0), *keep, *maxAge)
+}
+
+func runBackupRestore(args []string) error {
+	flags := flag.NewFlagSet("backup restore", flag.ExitOnError)
+	backupDir := flags.String("backups", "", "snapshot the current saves here before restoring (default: the snapshot's directory)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		backupUsage()
+		return fmt.Errorf("expected a snapshot and a save directory")
+	}
+	snapshotPath := flags.Arg(0)
+	saveDir := flags.Arg(1)
+
+	if *backupDir == "" {
+		*backupDir = filepath.Dir(snapshotPath)
+	}
+
+	// the saves being replaced are backed up first, so a restore can be undone
+	if _, err := os.Stat(saveDir); err == nil {
+		snapshot, created, err := backup.Create(saveDir, *backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to back up current saves: %w", err)
+		}
+		if created {
+			fmt.Printf("current saves backed up to %s\n", snapshot.Path)
+		}
+	}
+
+	err := backup.Restore(snapshotPath, saveDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("restored %s to %s\n", snapshotPath, saveDir)
+	return nil
+}
@@ -0,0 +1,345 @@
+// Package backup snapshots save directories into zip archives named after
+// their creation time and content hash, and restores them.
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"revision-go/remnant"
+	"revision-go/remnant/profile"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	manifestName    = "manifest.json"
+	timestampFormat = "20060102T150405Z"
+)
+
+type File struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// Summary describes the saves of a snapshot as read by the parser.
+type Summary struct {
+	Characters  int
+	Slots       []int
+	BuildNumber uint32
+	// parser errors, the files are backed up regardless
+	Errors []string
+}
+
+type Manifest struct {
+	Created time.Time
+	Source  string
+	// hash of the file names and contents, identical for identical saves
+	Hash    string
+	Files   []File
+	Summary Summary
+}
+
+type Snapshot struct {
+	Path     string
+	Manifest Manifest
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// contentHash combines the names and hashes of the files, sorted by name.
+func contentHash(files []File) string {
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00%s\n", file.Name, file.SHA256)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// regularFiles returns the names of the files a snapshot of saveDir holds,
+// sorted.
+func regularFiles(saveDir string) ([]string, error) {
+	entries, err := os.ReadDir(saveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func saveFiles(saveDir string) ([]File, error) {
+	names, err := regularFiles(saveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{}
+	for _, name := range names {
+		sum, size, err := hashFile(filepath.Join(saveDir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, File{
+			Name:   name,
+			Size:   size,
+			SHA256: sum,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func summarize(saveDir string) Summary {
+	summary := Summary{
+		Slots:  []int{},
+		Errors: []string{},
+	}
+
+	index, err := remnant.OpenSaveDir(saveDir)
+	if err != nil {
+		summary.Errors = append(summary.Errors, err.Error())
+		return summary
+	}
+
+	if index.ProfileError != "" {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", remnant.PROFILE_FILE_NAME, index.ProfileError))
+	}
+	if index.Profile != nil {
+		summary.BuildNumber = index.Profile.Header.BuildNumber
+
+		characters, err := profile.ReadCharacters(index.Profile)
+		if err != nil {
+			summary.Errors = append(summary.Errors, err.Error())
+		}
+		summary.Characters = len(characters)
+	}
+
+	for _, slot := range index.Slots {
+		summary.Slots = append(summary.Slots, slot.Index)
+		if slot.Error != "" {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", filepath.Base(slot.Path), slot.Error))
+		}
+	}
+
+	return summary
+}
+
+// Create snapshots the regular files of saveDir into backupDir. If a snapshot
+// with the same content already exists, it is returned instead and the bool
+// result is false.
+func Create(saveDir string, backupDir string) (Snapshot, bool, error) {
+	files, err := saveFiles(saveDir)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	if len(files) == 0 {
+		return Snapshot{}, false, fmt.Errorf("no files to back up in %s", saveDir)
+	}
+
+	hash := contentHash(files)
+
+	existing, err := List(backupDir)
+	if err != nil && !os.IsNotExist(err) {
+		return Snapshot{}, false, err
+	}
+	for _, snapshot := range existing {
+		if snapshot.Manifest.Hash == hash {
+			return snapshot, false, nil
+		}
+	}
+
+	source, err := filepath.Abs(saveDir)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	manifest := Manifest{
+		Created: time.Now().UTC(),
+		Source:  source,
+		Hash:    hash,
+		Files:   files,
+		Summary: summarize(saveDir),
+	}
+
+	err = os.MkdirAll(backupDir, 0755)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	name := fmt.Sprintf("%s_%s.zip", manifest.Created.Format(timestampFormat), hash[:12])
+	path := filepath.Join(backupDir, name)
+
+	err = writeArchive(path, saveDir, &manifest)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	return Snapshot{Path: path, Manifest: manifest}, true, nil
+}
+
+// writeArchive writes the zip next to its final path and renames it into
+// place, so that a partial archive is never listed.
+func writeArchive(path string, saveDir string, manifest *Manifest) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+
+	archive := zip.NewWriter(temp)
+
+	manifestWriter, err := archive.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(manifestWriter)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		err = addFile(archive, filepath.Join(saveDir, file.Name), file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return err
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
+
+func addFile(archive *zip.Writer, path string, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := archive.Create("saves/" + name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+func readManifest(path string) (Manifest, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer archive.Close()
+
+	file, err := archive.Open(manifestName)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer file.Close()
+
+	manifest := Manifest{}
+	err = json.NewDecoder(file).Decode(&manifest)
+	return manifest, err
+}
+
+// List returns the snapshots in backupDir, newest first.
+func List(backupDir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
+			continue
+		}
+
+		path := filepath.Join(backupDir, entry.Name())
+		manifest, err := readManifest(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		snapshots = append(snapshots, Snapshot{Path: path, Manifest: manifest})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Manifest.Created.After(snapshots[j].Manifest.Created)
+	})
+
+	return snapshots, nil
+}
+
+// Rotate removes snapshots beyond the newest keep ones and snapshots older
+// than maxAge. Zero disables either limit. The newest snapshot is never
+// removed.
+func Rotate(backupDir string, keep int, maxAge time.Duration) ([]Snapshot, error) {
+	snapshots, err := List(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []Snapshot{}
+	now := time.Now()
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			continue
+		}
+
+		tooMany := keep > 0 && i >= keep
+		tooOld := maxAge > 0 && now.Sub(snapshot.Manifest.Created) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		err = os.Remove(snapshot.Path)
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, snapshot)
+	}
+
+	return removed, nil
+}
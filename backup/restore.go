@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkName rejects manifest names that are not plain file names, which
+// would be extracted outside the target directory.
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\:`) {
+		return fmt.Errorf("invalid file name %q in manifest", name)
+	}
+	return nil
+}
+
+func extractFile(archive *zip.ReadCloser, file File, dir string) error {
+	source, err := archive.Open("saves/" + file.Name)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(filepath.Join(dir, file.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(target, hash), source)
+	if err != nil {
+		return err
+	}
+
+	if size != file.Size || hex.EncodeToString(hash.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("%s does not match the manifest", file.Name)
+	}
+
+	return target.Close()
+}
+
+// moveFiles moves the named files from one directory to another and returns
+// the names of the files it moved, all of them unless there is an error.
+func moveFiles(names []string, from string, to string) ([]string, error) {
+	for i, name := range names {
+		err := os.Rename(filepath.Join(from, name), filepath.Join(to, name))
+		if err != nil {
+			return names[:i], err
+		}
+	}
+	return names, nil
+}
+
+// Restore replaces the files of saveDir that a snapshot holds, its regular
+// files, with the files of a snapshot; subdirectories and other entries are
+// left alone. The files are extracted and verified in a directory next to
+// saveDir before any file is replaced; on failure saveDir is left as it was.
+func Restore(snapshotPath string, saveDir string) error {
+	manifest, err := readManifest(snapshotPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", snapshotPath, err)
+	}
+
+	names := make([]string, len(manifest.Files))
+	for i, file := range manifest.Files {
+		err = checkName(file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", snapshotPath, err)
+		}
+		names[i] = file.Name
+	}
+
+	archive, err := zip.OpenReader(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	saveDir = filepath.Clean(saveDir)
+	base := filepath.Base(saveDir)
+
+	temp, err := os.MkdirTemp(filepath.Dir(saveDir), "."+base+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(temp)
+
+	for _, file := range manifest.Files {
+		err = extractFile(archive, file, temp)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+		}
+	}
+
+	_, err = os.Stat(saveDir)
+	if os.IsNotExist(err) {
+		err = os.Chmod(temp, 0755)
+		if err != nil {
+			return err
+		}
+		return os.Rename(temp, saveDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := regularFiles(saveDir)
+	if err != nil {
+		return err
+	}
+
+	// the replaced files are moved aside until the snapshot is in place
+	old, err := os.MkdirTemp(filepath.Dir(saveDir), "."+base+".old-*")
+	if err != nil {
+		return err
+	}
+	keepOld := false
+	defer func() {
+		if !keepOld {
+			os.RemoveAll(old)
+		}
+	}()
+
+	moved, err := moveFiles(current, saveDir, old)
+	if err == nil {
+		var restored []string
+		restored, err = moveFiles(names, temp, saveDir)
+		if err != nil {
+			for _, name := range restored {
+				os.Remove(filepath.Join(saveDir, name))
+			}
+		}
+	}
+	if err != nil {
+		if _, restoreErr := moveFiles(moved, old, saveDir); restoreErr != nil {
+			keepOld = true
+			return fmt.Errorf("%w (previous saves kept in %s)", err, old)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/browse"
+	"revision-go/remnant"
+)
+
+func runBrowse(args []string) error {
+	flags := flag.NewFlagSet("browse", flag.ExitOnError)
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision browse [-names] save.sav")
+		fmt.Fprintln(flags.Output(), "Offsets are in the decompressed save data, as printed by hexmap.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one save file")
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return fmt.Errorf("browse needs a terminal")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	data, err := remnant.ReadData(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	archive, trace, err := remnant.TraceSaveArchive(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse save: %w", err)
+	}
+
+	tree, err := browse.NewTree(filepath.Base(flags.Arg(0)), &archive, trace, displayNames)
+	if err != nil {
+		return err
+	}
+
+	return browse.Run(tree)
+}
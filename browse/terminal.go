@@ -0,0 +1,125 @@
+package browse
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// escape sequences of the keys readKey knows, after ESC
+var escapeKeys = map[string]string{
+	"[A": keyUp, "OA": keyUp,
+	"[B": keyDown, "OB": keyDown,
+	"[C": keyRight, "OC": keyRight,
+	"[D": keyLeft, "OD": keyLeft,
+	"[H": keyHome, "OH": keyHome, "[1~": keyHome, "[7~": keyHome,
+	"[F": keyEnd, "OF": keyEnd, "[4~": keyEnd, "[8~": keyEnd,
+	"[5~": keyPageUp,
+	"[6~": keyPageDown,
+}
+
+// readKey reads a key from a terminal in raw mode. Unknown escape sequences
+// are returned as they are.
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return keyEnter, nil
+	case 0x7f, 0x08:
+		return keyBackspace, nil
+	case 0x03, 0x04:
+		// Ctrl-C and Ctrl-D are not turned into signals in raw mode
+		return "q", nil
+	case 0x1b:
+	default:
+		return string(c), nil
+	}
+
+	// a sequence arrives at once, a lone ESC is the escape key
+	if r.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	var sequence strings.Builder
+	for r.Buffered() > 0 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		sequence.WriteByte(b)
+		// sequences end with a letter or ~ after the first character
+		if sequence.Len() > 1 && (b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b == '~') {
+			break
+		}
+	}
+	if key, ok := escapeKeys[sequence.String()]; ok {
+		return key, nil
+	}
+	return "\x1b" + sequence.String(), nil
+}
+
+// Run shows the tree on the terminal of the standard input and output until
+// the user quits.
+func Run(tree *Tree) error {
+	restore, err := makeRaw()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	out := bufio.NewWriter(os.Stdout)
+	// alternate screen without cursor, restored on exit
+	out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		out.WriteString("\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	// keys are read in the background so that the screen is redrawn on
+	// resizes while waiting for one
+	type keyEvent struct {
+		key string
+		err error
+	}
+	keys := make(chan keyEvent)
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			key, err := readKey(in)
+			keys <- keyEvent{key, err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	resized, stop := watchResize()
+	defer stop()
+
+	view := NewView(tree)
+	width, height := terminalSize()
+	for {
+		var screen strings.Builder
+		view.Render(&screen, width, height)
+		out.WriteString(screen.String())
+		err = out.Flush()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-resized:
+			width, height = terminalSize()
+		case event := <-keys:
+			if event.err != nil {
+				return event.err
+			}
+			if view.HandleKey(event.key) {
+				return nil
+			}
+		}
+	}
+}
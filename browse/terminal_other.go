@@ -0,0 +1,21 @@
+//go:build !unix && !windows
+
+package browse
+
+import "fmt"
+
+// makeRaw fails on platforms without a terminal to switch to raw mode, such as
+// js/wasm.
+func makeRaw() (func(), error) {
+	return nil, fmt.Errorf("browse needs a terminal, which this platform does not have")
+}
+
+func terminalSize() (int, int) {
+	return 80, 24
+}
+
+// watchResize returns a channel that never receives, resizes cannot be
+// detected.
+func watchResize() (<-chan struct{}, func()) {
+	return nil, func() {}
+}
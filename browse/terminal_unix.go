@@ -0,0 +1,78 @@
+//go:build unix
+
+package browse
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// makeRaw switches the terminal to raw mode and returns a function that
+// restores the previous mode.
+func makeRaw() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal mode: %w", err)
+	}
+
+	_, err = stty("raw", "-echo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to switch terminal to raw mode: %w", err)
+	}
+
+	return func() { stty(state) }, nil
+}
+
+// terminalSize returns the width and height of the terminal, 80x24 if they
+// cannot be read. It runs stty, so it is only called again on resizes, see
+// watchResize.
+func terminalSize() (int, int) {
+	size, err := stty("size")
+	if err == nil {
+		var height, width int
+		_, err = fmt.Sscan(size, &height, &width)
+		if err == nil && width > 0 && height > 0 {
+			return width, height
+		}
+	}
+	return 80, 24
+}
+
+// watchResize returns a channel that receives a value when the terminal is
+// resized, on SIGWINCH, and a function that stops watching.
+func watchResize() (<-chan struct{}, func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	resized := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				select {
+				case resized <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return resized, func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,121 @@
+package browse
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// interval at which the console size is checked, consoles do not signal
+// resizes to programs reading escape sequences
+const resizeInterval = 200 * time.Millisecond
+
+const (
+	enableProcessedInput       = 0x0001
+	enableLineInput            = 0x0002
+	enableEchoInput            = 0x0004
+	enableVirtualTerminalInput = 0x0200
+
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+type smallRect struct {
+	left, top, right, bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	size              [2]int16
+	cursorPosition    [2]int16
+	attributes        uint16
+	window            smallRect
+	maximumWindowSize [2]int16
+}
+
+func setConsoleMode(handle syscall.Handle, mode uint32) error {
+	ok, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(mode))
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// makeRaw switches the console to raw input with escape sequences for input
+// and output, and returns a function that restores the previous modes.
+func makeRaw() (func(), error) {
+	in := syscall.Handle(os.Stdin.Fd())
+	out := syscall.Handle(os.Stdout.Fd())
+
+	var inMode, outMode uint32
+	err := syscall.GetConsoleMode(in, &inMode)
+	if err == nil {
+		err = syscall.GetConsoleMode(out, &outMode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read console mode: %w", err)
+	}
+
+	raw := inMode&^(enableProcessedInput|enableLineInput|enableEchoInput) | enableVirtualTerminalInput
+	err = setConsoleMode(in, raw)
+	if err == nil {
+		err = setConsoleMode(out, outMode|enableVirtualTerminalProcessing)
+	}
+	if err != nil {
+		setConsoleMode(in, inMode)
+		return nil, fmt.Errorf("failed to switch console to raw mode: %w", err)
+	}
+
+	return func() {
+		setConsoleMode(in, inMode)
+		setConsoleMode(out, outMode)
+	}, nil
+}
+
+// terminalSize returns the width and height of the console window, 80x24 if
+// they cannot be read.
+func terminalSize() (int, int) {
+	var info consoleScreenBufferInfo
+	ok, _, _ := procGetConsoleScreenBufferInfo.Call(uintptr(os.Stdout.Fd()), uintptr(unsafe.Pointer(&info)))
+	if ok == 0 {
+		return 80, 24
+	}
+	return int(info.window.right-info.window.left) + 1, int(info.window.bottom-info.window.top) + 1
+}
+
+// watchResize returns a channel that receives a value when the console is
+// resized, and a function that stops watching.
+func watchResize() (<-chan struct{}, func()) {
+	resized := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(resizeInterval)
+		defer ticker.Stop()
+
+		width, height := terminalSize()
+		for {
+			select {
+			case <-ticker.C:
+				newWidth, newHeight := terminalSize()
+				if newWidth == width && newHeight == height {
+					continue
+				}
+				width, height = newWidth, newHeight
+				select {
+				case resized <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return resized, func() { close(done) }
+}
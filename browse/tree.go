@@ -0,0 +1,356 @@
+// Package browse is an interactive terminal browser for parsed saves: the
+// objects, components and properties of a save are shown as a tree that can
+// be expanded, searched and followed along object references, with the range
+// of the decompressed save data each node was read from.
+package browse
+
+import (
+	"encoding/json"
+	"fmt"
+	"revision-go/names"
+	"revision-go/remnant"
+	"strconv"
+	"strings"
+)
+
+// maximum length of a value shown next to a node
+const maxValueLength = 200
+
+// Node is a node of the tree shown by the browser.
+type Node struct {
+	Label string
+	// property type or kind of the node
+	Type  string
+	Value string
+	Path  remnant.Path
+
+	Parent   *Node
+	Children []*Node
+	Depth    int
+
+	// range of the decompressed save data read for the node, Length is 0 if
+	// it is not known
+	Offset int64
+	Length int64
+
+	// path of the object an ObjectProperty refers to, nil for other nodes
+	Reference remnant.Path
+
+	// position in the depth-first order of the tree
+	index    int
+	expanded bool
+}
+
+// Tree is the tree of a save with its nodes in depth-first order.
+type Tree struct {
+	Root  *Node
+	Nodes []*Node
+}
+
+func (t *Tree) add(parent *Node, node *Node) *Node {
+	node.Parent = parent
+	if parent != nil {
+		node.Depth = parent.Depth + 1
+		parent.Children = append(parent.Children, node)
+	}
+	node.index = len(t.Nodes)
+	t.Nodes = append(t.Nodes, node)
+	return node
+}
+
+func truncate(text string) string {
+	if len(text) > maxValueLength {
+		return text[:maxValueLength-3] + "..."
+	}
+	return text
+}
+
+func formatJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return truncate(string(data))
+}
+
+func formatObject(value remnant.ObjectProperty) string {
+	if value.ObjectID == -1 {
+		return "none"
+	}
+	return fmt.Sprintf("%s (#%d)", value.ClassName, value.ObjectID)
+}
+
+// formatValue returns the value of a property, or a summary for containers
+// whose contents are children of the node.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return truncate(strconv.Quote(v))
+	case remnant.ObjectProperty:
+		return formatObject(v)
+	case remnant.EnumProperty:
+		return v.EnumValue
+	case remnant.StructProperty:
+		switch structValue := v.Value.(type) {
+		case []remnant.Property:
+			return v.Name
+		case remnant.PersistenceBlob:
+			return v.Name
+		case remnant.PersistenceContainer:
+			return fmt.Sprintf("%s, %d actors, %d destroyed", v.Name, len(structValue.Actors), len(structValue.Destroyed))
+		}
+		return v.Name + " " + formatJSON(v.Value)
+	case remnant.ArrayProperty:
+		return fmt.Sprintf("%s[%d]", v.ElementType, len(v.Items))
+	case remnant.ArrayStructProperty:
+		return fmt.Sprintf("%s[%d]", v.ElementType, len(v.Items))
+	case remnant.MapProperty:
+		return fmt.Sprintf("map[%s]%s, %d entries", v.KeyType, v.ValueType, len(v.Values))
+	case remnant.Variables:
+		return v.Name
+	}
+	return formatJSON(value)
+}
+
+// reference returns the path of the object a value refers to in the archive
+// data, nil if it is not an object reference.
+func reference(data *remnant.SaveData, dataPath remnant.Path, value interface{}) remnant.Path {
+	object, ok := value.(remnant.ObjectProperty)
+	if !ok || object.ObjectID < 0 {
+		return nil
+	}
+	for i := range data.Objects {
+		if data.Objects[i].ObjectID == uint32(object.ObjectID) {
+			return dataPath.Append("Objects", strconv.Itoa(i))
+		}
+	}
+	return nil
+}
+
+func actorClass(actor *remnant.Actor) string {
+	if actor.DynamicData != nil {
+		return actor.DynamicData.ClassPath.Path
+	}
+	if len(actor.Archive.Objects) > 0 {
+		return actor.Archive.Objects[0].ObjectPath
+	}
+	return ""
+}
+
+// nameKey returns the asset path or enum value of a value, which display
+// names are looked up by.
+func nameKey(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case remnant.ObjectProperty:
+		return v.ClassName
+	case remnant.EnumProperty:
+		return v.EnumValue
+	}
+	return ""
+}
+
+// withName prefixes text with the display name for key, if there is one.
+func withName(displayNames *names.Database, key string, text string) string {
+	if name := displayNames.DisplayName(key, ""); key != "" && name != "" {
+		return name + ": " + text
+	}
+	return text
+}
+
+// NewTree builds the tree of a save. The offsets of nodes are taken from
+// trace, which may be nil; values are shown with their display names in
+// displayNames, which may be nil too.
+func NewTree(name string, archive *remnant.SaveArchive, trace *remnant.Trace, displayNames *names.Database) (*Tree, error) {
+	t := &Tree{}
+
+	// nodes containing the current one, innermost last
+	parents := []*Node{}
+	// archive data containing the current node, for object references
+	type scope struct {
+		data *remnant.SaveData
+		path remnant.Path
+	}
+	scopes := []scope{}
+	// element types of the arrays of scalars, by their nodes
+	elementTypes := map[*Node]string{}
+
+	err := remnant.Walk(archive, func(path remnant.Path, node remnant.Node) error {
+		key := path.String()
+		for len(parents) > 0 && !strings.HasPrefix(key, parents[len(parents)-1].Path.String()+"/") {
+			parents = parents[:len(parents)-1]
+		}
+		var parent *Node
+		if len(parents) > 0 {
+			parent = parents[len(parents)-1]
+		}
+		for len(scopes) > 0 && !strings.HasPrefix(key, scopes[len(scopes)-1].path.String()+"/") {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		last := ""
+		if len(path) > 0 {
+			last = path[len(path)-1]
+		}
+		n := &Node{Label: "[" + last + "]", Path: path}
+
+		switch v := node.(type) {
+		case *remnant.SaveData:
+			n.Label = "Archive"
+			if parent == nil {
+				n.Label = name
+			}
+			n.Type = "SaveData"
+			n.Value = fmt.Sprintf("%d objects", len(v.Objects))
+			scopes = append(scopes, scope{data: v, path: path})
+
+		case *remnant.UObject:
+			n.Label = "Object " + last
+			n.Type = "Object"
+			n.Value = withName(displayNames, v.ObjectPath, v.ObjectPath)
+
+		case *remnant.Component:
+			n.Label = "Component " + v.ComponentKey
+			n.Type = "Component"
+
+		case *remnant.Property:
+			n.Label = last
+			n.Type = v.Type
+			n.Value = withName(displayNames, nameKey(v.Value), formatValue(v.Value))
+			n.Reference = reference(scopes[len(scopes)-1].data, scopes[len(scopes)-1].path, v.Value)
+			if array, ok := v.Value.(remnant.ArrayProperty); ok {
+				elementTypes[n] = array.ElementType
+			}
+
+		case *interface{}:
+			n.Type = elementTypes[parent]
+			n.Value = withName(displayNames, nameKey(*v), formatValue(*v))
+			n.Reference = reference(scopes[len(scopes)-1].data, scopes[len(scopes)-1].path, *v)
+
+		case *remnant.StructProperty:
+			n.Type = "StructProperty"
+			n.Value = formatValue(*v)
+
+		case *remnant.MapPropertyValue:
+			n.Type = "MapEntry"
+			n.Value = withName(displayNames, nameKey(v.Key), formatValue(v.Key)) + ": " + withName(displayNames, nameKey(v.Value), formatValue(v.Value))
+			n.Reference = reference(scopes[len(scopes)-1].data, scopes[len(scopes)-1].path, v.Value)
+
+		case *remnant.Actor:
+			n.Label = "Actor " + last
+			n.Type = "Actor"
+			n.Value = withName(displayNames, actorClass(v), actorClass(v))
+			if v.DynamicData != nil {
+				n.Value += " (dynamic)"
+			}
+		}
+
+		t.add(parent, n)
+		parents = append(parents, n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Nodes) == 0 {
+		return nil, fmt.Errorf("save has no data")
+	}
+
+	t.Root = t.Nodes[0]
+	t.Root.expanded = true
+	if trace != nil {
+		t.addOffsets(trace)
+	}
+	return t, nil
+}
+
+func (n *Node) extend(offset int64, length int64) {
+	if length == 0 {
+		return
+	}
+	if n.Length == 0 {
+		n.Offset, n.Length = offset, length
+		return
+	}
+	end := n.Offset + n.Length
+	if offset+length > end {
+		end = offset + length
+	}
+	if offset < n.Offset {
+		n.Offset = offset
+	}
+	n.Length = end - n.Offset
+}
+
+// addOffsets sets the range of every node to the fields traced below its
+// path.
+func (t *Tree) addOffsets(trace *remnant.Trace) {
+	byPath := make(map[string]*Node, len(t.Nodes))
+	for _, n := range t.Nodes {
+		byPath[n.Path.String()] = n
+	}
+
+	for _, entry := range trace.Entries {
+		for depth := len(entry.Path); depth >= 0; depth-- {
+			if n, ok := byPath[entry.Path[:depth].String()]; ok {
+				n.extend(entry.Offset, entry.Length)
+				break
+			}
+		}
+	}
+
+	// children come after their parent, so every node is complete before it
+	// is added to its parent
+	for i := len(t.Nodes) - 1; i > 0; i-- {
+		n := t.Nodes[i]
+		n.Parent.extend(n.Offset, n.Length)
+	}
+}
+
+// Find returns the node at path, nil if there is none.
+func (t *Tree) Find(path remnant.Path) *Node {
+	n := t.Root
+	for n != nil && len(n.Path) < len(path) {
+		var next *Node
+		for _, child := range n.Children {
+			if len(child.Path) <= len(path) && remnant.Path(path[:len(child.Path)]).String() == child.Path.String() {
+				next = child
+				break
+			}
+		}
+		n = next
+	}
+	if n == nil || n.Path.String() != path.String() {
+		return nil
+	}
+	return n
+}
+
+func (n *Node) matches(text string) bool {
+	return strings.Contains(strings.ToLower(n.Label), text) ||
+		strings.Contains(strings.ToLower(n.Type), text) ||
+		strings.Contains(strings.ToLower(n.Value), text)
+}
+
+// Search returns the first node after from, or before it if backward is set,
+// whose label, type or value contains text, ignoring case. The search wraps
+// around the end of the tree.
+func (t *Tree) Search(from *Node, text string, backward bool) *Node {
+	text = strings.ToLower(text)
+	step := 1
+	if backward {
+		step = -1
+	}
+
+	count := len(t.Nodes)
+	for i := 1; i <= count; i++ {
+		n := t.Nodes[((from.index+i*step)%count+count)%count]
+		if n.matches(text) {
+			return n
+		}
+	}
+	return nil
+}
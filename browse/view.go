@@ -0,0 +1,344 @@
+package browse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// keys returned by readKey besides printable characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+)
+
+const (
+	styleReset    = "\x1b[0m"
+	styleSelected = "\x1b[7m"
+	styleType     = "\x1b[36m"
+	styleStatus   = "\x1b[1m"
+)
+
+const help = "arrows move, enter/space expand, / search, n/N next/previous, r follow reference, b back, q quit"
+
+// lines at the bottom of the screen used for the status
+const statusLines = 2
+
+// View is the state of the browser: the expanded nodes, the selection and
+// the search. It does not depend on the terminal, see Run.
+type View struct {
+	tree *Tree
+	// expanded nodes in the order they are shown
+	visible []*Node
+	cursor  int
+	// index of the first visible node on the screen
+	top int
+	// page size of the last Render
+	rows int
+
+	// nodes the selection was on before following references
+	history []*Node
+
+	search string
+	// whether the search prompt is shown, with the text typed so far
+	editing bool
+	input   []rune
+	message string
+}
+
+// NewView returns a view of tree with the root expanded and selected.
+func NewView(tree *Tree) *View {
+	v := &View{tree: tree, rows: 1}
+	v.refresh()
+	return v
+}
+
+func (v *View) refresh() {
+	selected := v.Selected()
+
+	v.visible = v.visible[:0]
+	var add func(n *Node)
+	add = func(n *Node) {
+		v.visible = append(v.visible, n)
+		if n.expanded {
+			for _, child := range n.Children {
+				add(child)
+			}
+		}
+	}
+	add(v.tree.Root)
+
+	v.cursor = 0
+	for i, n := range v.visible {
+		if n == selected {
+			v.cursor = i
+		}
+	}
+}
+
+// Selected returns the node under the cursor.
+func (v *View) Selected() *Node {
+	if v.cursor < len(v.visible) {
+		return v.visible[v.cursor]
+	}
+	return v.tree.Root
+}
+
+// Select expands the parents of n and moves the cursor to it.
+func (v *View) Select(n *Node) {
+	for parent := n.Parent; parent != nil; parent = parent.Parent {
+		parent.expanded = true
+	}
+	v.refresh()
+	for i, visible := range v.visible {
+		if visible == n {
+			v.cursor = i
+		}
+	}
+	// show the node in the middle of the screen if it is off screen
+	if v.cursor < v.top || v.cursor >= v.top+v.rows {
+		v.top = v.cursor - v.rows/2
+	}
+}
+
+func (v *View) move(delta int) {
+	v.cursor += delta
+	if v.cursor >= len(v.visible) {
+		v.cursor = len(v.visible) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *View) setExpanded(n *Node, expanded bool) {
+	if len(n.Children) > 0 && n.expanded != expanded {
+		n.expanded = expanded
+		v.refresh()
+	}
+}
+
+func (v *View) find(backward bool) {
+	if v.search == "" {
+		v.message = "nothing to search for, press / to search"
+		return
+	}
+	n := v.tree.Search(v.Selected(), v.search, backward)
+	if n == nil {
+		v.message = fmt.Sprintf("%q not found", v.search)
+		return
+	}
+	v.Select(n)
+}
+
+func (v *View) follow() {
+	n := v.Selected()
+	if n.Reference == nil {
+		v.message = "the selected node is not an object reference"
+		return
+	}
+	target := v.tree.Find(n.Reference)
+	if target == nil {
+		v.message = fmt.Sprintf("%s is not in the save", n.Reference)
+		return
+	}
+	v.history = append(v.history, n)
+	v.Select(target)
+}
+
+func (v *View) back() {
+	if len(v.history) == 0 {
+		v.message = "no reference was followed"
+		return
+	}
+	n := v.history[len(v.history)-1]
+	v.history = v.history[:len(v.history)-1]
+	v.Select(n)
+}
+
+func (v *View) handlePrompt(key string) {
+	switch key {
+	case keyEnter:
+		v.editing = false
+		v.search = string(v.input)
+		v.find(false)
+	case keyEscape:
+		v.editing = false
+	case keyBackspace:
+		if len(v.input) > 0 {
+			v.input = v.input[:len(v.input)-1]
+		}
+	default:
+		if r, size := utf8.DecodeRuneInString(key); size == len(key) && r >= ' ' {
+			v.input = append(v.input, r)
+		}
+	}
+}
+
+// HandleKey updates the view for a key read by readKey and reports whether
+// the browser should quit.
+func (v *View) HandleKey(key string) bool {
+	v.message = ""
+	if v.editing {
+		v.handlePrompt(key)
+		return false
+	}
+
+	n := v.Selected()
+	switch key {
+	case "q", keyEscape:
+		return true
+	case keyUp, "k":
+		v.move(-1)
+	case keyDown, "j":
+		v.move(1)
+	case keyPageUp:
+		v.move(-v.rows)
+	case keyPageDown:
+		v.move(v.rows)
+	case keyHome, "g":
+		v.move(-len(v.visible))
+	case keyEnd, "G":
+		v.move(len(v.visible))
+	case keyRight, "l":
+		if n.expanded && len(n.Children) > 0 {
+			v.move(1)
+		}
+		v.setExpanded(n, true)
+	case keyLeft, "h":
+		if n.expanded && len(n.Children) > 0 {
+			v.setExpanded(n, false)
+		} else if n.Parent != nil {
+			v.Select(n.Parent)
+		}
+	case keyEnter, " ":
+		if len(n.Children) == 0 && n.Reference != nil {
+			v.follow()
+		} else {
+			v.setExpanded(n, !n.expanded)
+		}
+	case "/":
+		v.editing = true
+		v.input = []rune{}
+	case "n":
+		v.find(false)
+	case "N":
+		v.find(true)
+	case "r":
+		v.follow()
+	case "b", keyBackspace:
+		v.back()
+	}
+	return false
+}
+
+// fit cuts text to width runes.
+func fit(text string, width int) (string, int) {
+	count := 0
+	for i := range text {
+		if count == width {
+			return text[:i], count
+		}
+		count++
+	}
+	return text, count
+}
+
+func (v *View) renderNode(sb *strings.Builder, n *Node, width int, selected bool) {
+	marker := "  "
+	switch {
+	case len(n.Children) > 0 && n.expanded:
+		marker = "▾ "
+	case len(n.Children) > 0:
+		marker = "▸ "
+	case n.Reference != nil:
+		marker = "→ "
+	}
+
+	if selected {
+		sb.WriteString(styleSelected)
+	}
+
+	// the label, type and value share the width in this order
+	parts := []string{strings.Repeat("  ", n.Depth) + marker + n.Label, " " + n.Type, " " + n.Value}
+	styles := []string{"", styleType, ""}
+	for i, part := range parts {
+		text, used := fit(part, width)
+		width -= used
+		if styles[i] != "" && !selected {
+			text = styles[i] + text + styleReset
+		}
+		sb.WriteString(text)
+	}
+	if selected {
+		sb.WriteString(strings.Repeat(" ", width))
+		sb.WriteString(styleReset)
+	}
+}
+
+func (v *View) status() (string, string) {
+	n := v.Selected()
+
+	location := n.Path.String()
+	if location == "" {
+		location = "/"
+	}
+	if n.Length > 0 {
+		location += fmt.Sprintf("  offset 0x%x-0x%x (%d bytes)", n.Offset, n.Offset+n.Length, n.Length)
+	}
+	if n.Reference != nil {
+		location += "  → " + n.Reference.String()
+	}
+
+	switch {
+	case v.editing:
+		return location, "/" + string(v.input)
+	case v.message != "":
+		return location, v.message
+	}
+	return location, help
+}
+
+// Render writes the screen for a terminal of the given size, see Run.
+func (v *View) Render(sb *strings.Builder, width int, height int) {
+	v.rows = height - statusLines
+	if v.rows < 1 {
+		v.rows = 1
+	}
+
+	if v.cursor < v.top {
+		v.top = v.cursor
+	}
+	if v.cursor >= v.top+v.rows {
+		v.top = v.cursor - v.rows + 1
+	}
+	if v.top > len(v.visible)-v.rows {
+		v.top = len(v.visible) - v.rows
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+
+	sb.WriteString("\x1b[H")
+	for row := 0; row < v.rows; row++ {
+		if i := v.top + row; i < len(v.visible) {
+			v.renderNode(sb, v.visible[i], width, i == v.cursor)
+		}
+		sb.WriteString("\x1b[K\r\n")
+	}
+
+	location, line := v.status()
+	location, _ = fit(location, width)
+	line, _ = fit(line, width)
+	sb.WriteString(styleStatus + location + styleReset + "\x1b[K\r\n")
+	sb.WriteString(line + "\x1b[K")
+}
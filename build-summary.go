@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/names"
+	"revision-go/remnant/profile"
+	"revision-go/ue"
+)
+
+func runBuild(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print builds as JSON")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision build [-json] [-names] profile.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one profile save")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	characters, err := profile.ReadCharacters(&archive)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type characterBuild struct {
+			Index int
+			Build profile.Build
+		}
+		builds := make([]characterBuild, len(characters))
+		for i, character := range characters {
+			builds[i] = characterBuild{Index: character.Index, Build: character.Build}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(builds)
+	}
+
+	for _, character := range characters {
+		build := character.Build
+
+		fmt.Printf("Character %d\n", character.Index)
+		fmt.Printf("  Primary archetype:   %s\n", displayName(displayNames, build.PrimaryArchetype))
+		fmt.Printf("  Secondary archetype: %s\n", displayName(displayNames, build.SecondaryArchetype))
+		for _, archetype := range build.Archetypes {
+			fmt.Printf("  Archetype %s: level %d\n", displayName(displayNames, archetype.BlueprintPath), archetype.Level)
+		}
+		fmt.Printf("  Trait points: %d\n", build.TraitPoints)
+		for _, trait := range build.Traits {
+			fmt.Printf("  Trait %s: %d\n", displayName(displayNames, trait.BlueprintPath), trait.Level)
+		}
+		for _, skill := range build.EquippedSkills {
+			fmt.Printf("  Skill slot %d: %s\n", skill.EquipmentSlot, displayName(displayNames, skill.BlueprintPath))
+		}
+	}
+
+	return nil
+}
+
+func displayName(displayNames *names.Database, blueprintPath string) string {
+	return displayNames.DisplayName(blueprintPath, ue.AssetName(blueprintPath))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/names"
+	"revision-go/remnant/profile"
+	"revision-go/ue"
+	"strings"
+)
+
+func readCatalog(path string) ([]profile.CatalogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var catalog []profile.CatalogEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		catalog, err = profile.ReadCatalogCSV(file)
+	case ".json":
+		catalog, err = profile.ReadCatalogJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %s, expected .json or .csv", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return catalog, nil
+}
+
+func runChecklist(args []string) error {
+	flags := flag.NewFlagSet("checklist", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the checklist as JSON")
+	characterIndex := flags.Int("character", -1, "only check the character in this slot (default: all characters)")
+	showOwned := flags.Bool("owned", false, "also list owned entries")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision checklist [-json] [-owned] [-names] [-character N] profile.sav catalog.json|catalog.csv")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a profile save and a catalog file")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	catalog, err := readCatalog(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	characters, err := profile.ReadCharacters(&archive)
+	if err != nil {
+		return err
+	}
+
+	if *characterIndex >= 0 {
+		selected := []profile.Character{}
+		for _, character := range characters {
+			if character.Index == *characterIndex {
+				selected = append(selected, character)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no character in slot %d", *characterIndex)
+		}
+		characters = selected
+	}
+
+	// entries without a category are grouped by their category in the name
+	// database
+	for i := range catalog {
+		if catalog[i].Category == "" {
+			catalog[i].Category = displayNames.Category(catalog[i].BlueprintPath)
+		}
+	}
+
+	statuses := profile.Checklist(catalog, characters)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	for _, status := range statuses {
+		fmt.Printf("%s: %d/%d (%.1f%%)\n",
+			status.Category, len(status.Owned), len(status.Owned)+len(status.Missing), status.Completion)
+		if *showOwned {
+			for _, entry := range status.Owned {
+				fmt.Printf("  + %s\n", entryName(entry, displayNames))
+			}
+		}
+		for _, entry := range status.Missing {
+			fmt.Printf("  - %s\n", entryName(entry, displayNames))
+		}
+	}
+
+	return nil
+}
+
+func entryName(entry profile.CatalogEntry, displayNames *names.Database) string {
+	if entry.Name != "" {
+		return entry.Name
+	}
+	return displayNames.DisplayName(entry.BlueprintPath, ue.AssetName(entry.BlueprintPath))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant/schema"
+	"strings"
+)
+
+func runCodegen(args []string) error {
+	flags := flag.NewFlagSet("codegen", flag.ExitOnError)
+	pkg := flags.String("package", "saves", "package name of the generated code")
+	types := flags.String("types", "", "comma separated schema or Go names of the types to generate, with the structs they use (default: all)")
+	output := flags.String("o", "", "output file (default: standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision codegen [-package saves] [-types A,B] [-o file] schema.json")
+		fmt.Fprintln(flags.Output(), "The schema is written by revision schema -format schema, or by hand.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one schema file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	s, err := schema.Read(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+
+	var selected []string
+	if *types != "" {
+		selected = strings.Split(*types, ",")
+	}
+
+	if *output == "" {
+		return s.WriteGo(os.Stdout, *pkg, selected)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = s.WriteGo(out, *pkg, selected)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
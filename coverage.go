@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"revision-go/remnant"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// findSaves returns the .sav files among paths, searching directories
+// recursively.
+func findSaves(paths []string) ([]string, error) {
+	saves := []string{}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if file == path && !entry.IsDir() || !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".sav") {
+				saves = append(saves, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(saves)
+	return saves, nil
+}
+
+func addCoverage(coverage *remnant.Coverage, path string) (err error) {
+	// saves of unknown formats are what coverage is run on, one that trips
+	// up the reader must not end the run
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("reader failed: %v", recovered)
+		}
+	}()
+
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := remnant.DecodeData(bytes.NewReader(fileData))
+	if err != nil {
+		return fmt.Errorf("invalid save file: %w", err)
+	}
+
+	archive, err := remnant.ReadSaveArchive(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse save: %w", err)
+	}
+
+	coverage.Add(path, len(data), &archive)
+	return nil
+}
+
+func printUnreadGroups(w io.Writer, title string, groups []remnant.UnreadGroup, top int) {
+	fmt.Fprintf(w, "\n%s\n", title)
+	fmt.Fprintln(w, "KEY\tCOUNT\tFILES\tBYTES\tSAMPLES")
+	for i, group := range groups {
+		if top > 0 && i == top {
+			fmt.Fprintf(w, "(%d more)\t\t\t\t\n", len(groups)-top)
+			break
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t", group.Key, group.Occurrences, group.Files, group.Bytes)
+		if len(group.Samples) == 0 {
+			fmt.Fprintln(w)
+		}
+		for j, sample := range group.Samples {
+			if j > 0 {
+				fmt.Fprint(w, "\t\t\t\t")
+			}
+			fmt.Fprintf(w, "%s (%d)\n", sample.Data, sample.Count)
+		}
+	}
+}
+
+func runCoverage(args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	samples := flags.Int("samples", 3, "distinct byte patterns kept per group")
+	top := flags.Int("top", 20, "groups printed per table, 0 for all")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision coverage [-json] [-samples 3] [-top 20] dir|file.sav...")
+		fmt.Fprintln(flags.Output(), "Directories are searched recursively for .sav files.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one directory or save file")
+	}
+
+	saves, err := findSaves(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(saves) == 0 {
+		return fmt.Errorf("no save files found")
+	}
+
+	// the reader logs every unread range, which is what is collected here
+	log.SetOutput(io.Discard)
+	coverage := remnant.NewCoverage(*samples)
+	for _, save := range saves {
+		err := addCoverage(coverage, save)
+		if err != nil {
+			coverage.AddError(save, err)
+		}
+	}
+	log.SetOutput(os.Stderr)
+
+	report := coverage.Report()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	percent := 0.0
+	if report.Bytes > 0 {
+		percent = float64(report.UnreadBytes) * 100 / float64(report.Bytes)
+	}
+	fmt.Printf("%d saves, %d failed to parse, %d of %d bytes unread (%.2f%%)\n",
+		report.Files, len(report.Errors), report.UnreadBytes, report.Bytes, percent)
+	for _, saveError := range report.Errors {
+		fmt.Printf("  %s: %s\n", saveError.File, saveError.Error)
+	}
+
+	if report.UnreadBytes == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printUnreadGroups(w, "By component key", report.ComponentKeys, *top)
+	printUnreadGroups(w, "By object path", report.ObjectPaths, *top)
+	printUnreadGroups(w, "By last property type", report.PropertyTypes, *top)
+	return w.Flush()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/names"
+	"revision-go/remnant"
+)
+
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print changes as a JSON array")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision diff [-json] [-names] old.sav new.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected two save files")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	oldArchive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	newArchive, err := loadSave(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	changes := remnant.Diff(&oldArchive, &newArchive)
+
+	if *asJSON {
+		if changes == nil {
+			changes = []remnant.Change{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	for _, change := range changes {
+		err = printChange(change, displayNames)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatValue prints value as JSON, followed by the display name of the asset
+// or enum value it refers to, if there is one.
+func formatValue(value interface{}, displayNames *names.Database) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	var key string
+	switch v := value.(type) {
+	case string:
+		key = v
+	case remnant.ObjectProperty:
+		key = v.ClassName
+	case remnant.EnumProperty:
+		key = v.EnumValue
+	}
+	if name := displayNames.DisplayName(key, ""); key != "" && name != "" {
+		return fmt.Sprintf("%s (%s)", data, name)
+	}
+
+	return string(data)
+}
+
+func printChange(change remnant.Change, displayNames *names.Database) error {
+	var err error
+	switch change.Type {
+	case remnant.ChangeAdded:
+		_, err = fmt.Printf("+ %s: %s\n", change.Path, formatValue(change.New, displayNames))
+	case remnant.ChangeRemoved:
+		_, err = fmt.Printf("- %s: %s\n", change.Path, formatValue(change.Old, displayNames))
+	default:
+		_, err = fmt.Printf("~ %s: %s -> %s\n", change.Path, formatValue(change.Old, displayNames), formatValue(change.New, displayNames))
+	}
+	return err
+}
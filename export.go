@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"revision-go/remnant/tables"
+)
+
+func writeCSVTables(dir string, exported *tables.Tables) error {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range exported.List() {
+		file, err := os.Create(filepath.Join(dir, table.Name+".csv"))
+		if err != nil {
+			return err
+		}
+
+		err = table.WriteCSV(file)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		err = file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "sql", "output format: sql (a script of INSERT statements) or csv (a file per table)")
+	output := flags.String("o", "", "output file for sql, directory for csv (default: standard output for sql, export for csv)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision export [-format sql|csv] [-o file|dir] dir|file.sav...")
+		fmt.Fprintln(flags.Output(), "Directories are searched recursively for .sav files.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one directory or save file")
+	}
+	if *format != "sql" && *format != "csv" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	saves, err := findSaves(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	exported := tables.New()
+	added := 0
+	for _, save := range saves {
+		log.SetOutput(io.Discard)
+		archive, err := loadSave(save)
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			log.Printf("skipping %s", err)
+			continue
+		}
+
+		err = exported.Add(filepath.ToSlash(save), &archive)
+		if err != nil {
+			return fmt.Errorf("%s: %w", save, err)
+		}
+		added++
+	}
+	if added == 0 {
+		return fmt.Errorf("no save files could be parsed")
+	}
+
+	if *format == "csv" {
+		dir := *output
+		if dir == "" {
+			dir = "export"
+		}
+		return writeCSVTables(dir, exported)
+	}
+
+	if *output == "" {
+		return exported.WriteSQL(os.Stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = exported.WriteSQL(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant"
+	"strings"
+)
+
+const (
+	hexmapRowSize = 16
+	// rows printed per field unless -full is given
+	hexmapMaxRows = 4
+)
+
+// layoutEntry is a traced field, or a range of data no field covers.
+type layoutEntry struct {
+	remnant.TraceEntry
+	Untraced bool
+	Data     string
+}
+
+func layoutEntries(data []byte, trace *remnant.Trace) []layoutEntry {
+	entries := make([]layoutEntry, 0, len(trace.Entries))
+	covered := int64(0)
+
+	addUntraced := func(end int64) {
+		if end > covered {
+			entries = append(entries, layoutEntry{
+				TraceEntry: remnant.TraceEntry{Offset: covered, Length: end - covered},
+				Untraced:   true,
+			})
+		}
+	}
+
+	for _, entry := range trace.Entries {
+		addUntraced(entry.Offset)
+		entries = append(entries, layoutEntry{TraceEntry: entry})
+		if end := entry.Offset + entry.Length; end > covered {
+			covered = end
+		}
+	}
+	addUntraced(int64(len(data)))
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.Data = hex.EncodeToString(data[entry.Offset : entry.Offset+entry.Length])
+	}
+
+	return entries
+}
+
+func hasPathPrefix(path remnant.Path, prefix remnant.Path) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatTraceValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	text := string(data)
+	if len(text) > 80 {
+		text = text[:77] + "..."
+	}
+	return " = " + text
+}
+
+func printHexmapEntry(w *bufio.Writer, data []byte, entry layoutEntry, full bool, color bool) {
+	label := strings.TrimSpace(entry.Path.String() + " " + entry.Field + formatTraceValue(entry.Value))
+	highlight := false
+	switch {
+	case entry.Untraced:
+		label = "(not parsed)"
+		highlight = true
+	case entry.Unread:
+		label = strings.TrimSpace(entry.Path.String() + " UNREAD")
+		highlight = true
+	}
+
+	bytes := data[entry.Offset : entry.Offset+entry.Length]
+	for row := 0; len(bytes) > 0; row++ {
+		if !full && row == hexmapMaxRows {
+			fmt.Fprintf(w, "%08x  ... %d more bytes\n", entry.Offset+int64(row*hexmapRowSize), len(bytes))
+			break
+		}
+
+		size := len(bytes)
+		if size > hexmapRowSize {
+			size = hexmapRowSize
+		}
+
+		var hexBytes strings.Builder
+		for i, b := range bytes[:size] {
+			if i == hexmapRowSize/2 {
+				hexBytes.WriteByte(' ')
+			}
+			fmt.Fprintf(&hexBytes, "%02x ", b)
+		}
+
+		text := ""
+		if row == 0 {
+			text = label
+		}
+
+		line := fmt.Sprintf("%08x  %-49s %s", entry.Offset+int64(row*hexmapRowSize), hexBytes.String(), text)
+		if highlight && color {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+
+		bytes = bytes[size:]
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func runHexmap(args []string) error {
+	flags := flag.NewFlagSet("hexmap", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the layout as JSON")
+	pathPrefix := flags.String("path", "", "only show fields below this path")
+	full := flags.Bool("full", false, fmt.Sprintf("print all bytes of fields longer than %d bytes", hexmapMaxRows*hexmapRowSize))
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision hexmap [-json] [-path /Objects/0] [-full] file.sav")
+		fmt.Fprintln(flags.Output(), "Offsets are in the decompressed save data.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one save file")
+	}
+
+	prefix, err := remnant.ParsePath(*pathPrefix)
+	if err != nil {
+		return err
+	}
+
+	data, err := remnant.ReadData(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// a partial layout is what is needed to find out why parsing failed
+	_, trace, parseErr := remnant.TraceSaveArchive(bytes.NewReader(data))
+
+	entries := layoutEntries(data, trace)
+	if len(prefix) > 0 {
+		filtered := []layoutEntry{}
+		for _, entry := range entries {
+			if !entry.Untraced && hasPathPrefix(entry.Path, prefix) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+		if err != nil {
+			return err
+		}
+	} else {
+		w := bufio.NewWriter(os.Stdout)
+		color := isTerminal(os.Stdout)
+		for _, entry := range entries {
+			printHexmapEntry(w, data, entry, *full, color)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	if parseErr != nil {
+		return fmt.Errorf("parsing stopped: %w", parseErr)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/remnant"
+	"strings"
+)
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	output := flags.String("o", "", "output save file (default: input name with .sav extension)")
+	format := flags.String("format", "", "input format: json or yaml (default: by extension, .yaml and .yml are yaml)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision import [-o file.sav] [-format json|yaml] file.json|file.yaml")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one JSON or YAML file")
+	}
+	inputPath := flags.Arg(0)
+
+	read := remnant.ReadJSONDocument
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(inputPath)) {
+		case ".yaml", ".yml":
+			*format = "yaml"
+		default:
+			*format = "json"
+		}
+	}
+	switch *format {
+	case "json":
+	case "yaml":
+		read = remnant.ReadYAMLDocument
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".sav"
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := read(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", inputPath, err)
+	}
+
+	var data bytes.Buffer
+	err = remnant.WriteSaveArchive(&data, &archive)
+	if err != nil {
+		return fmt.Errorf("failed to encode save: %w", err)
+	}
+
+	return remnant.WriteData(outputPath, data.Bytes())
+}
@@ -0,0 +1,254 @@
+// Package tree is a document of ordered mappings, sequences and scalars that
+// converts from and to JSON. It is the node type of the yaml package and is
+// used to rewrite JSON documents without losing the order of object keys.
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	ScalarNode Kind = iota
+	SequenceNode
+	MappingNode
+)
+
+// Node is a node of a document. Mappings keep their keys in order.
+type Node struct {
+	Kind Kind
+	// local tag without the leading "!", empty if the node is untagged
+	Tag string
+	// text of a scalar
+	Value string
+	// String is set for quoted scalars, which are strings whatever their text
+	String bool
+	// keys of a mapping, the value of Keys[i] is Items[i]
+	Keys  []string
+	Items []*Node
+	// line the node was read from, 0 for nodes that were not parsed
+	Line int
+}
+
+func NewString(s string) *Node {
+	return &Node{Kind: ScalarNode, Value: s, String: true}
+}
+
+// NewScalar returns a plain scalar, resolved by its text: null, a boolean, a
+// number or a string.
+func NewScalar(text string) *Node {
+	return &Node{Kind: ScalarNode, Value: text}
+}
+
+func NewSequence(items ...*Node) *Node {
+	return &Node{Kind: SequenceNode, Items: items}
+}
+
+func NewMapping() *Node {
+	return &Node{Kind: MappingNode, Items: []*Node{}}
+}
+
+// Append adds an entry to a mapping.
+func (n *Node) Append(key string, value *Node) {
+	n.Keys = append(n.Keys, key)
+	n.Items = append(n.Items, value)
+}
+
+// Get returns the value of the first entry of a mapping with the given key.
+func (n *Node) Get(key string) *Node {
+	for i, k := range n.Keys {
+		if k == key {
+			return n.Items[i]
+		}
+	}
+	return nil
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	floatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	jsonNumber   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// Resolve returns the type of a plain scalar with the given text as resolved
+// by the YAML core schema: null, bool, int, float or str.
+func Resolve(text string) string {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return "null"
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return "bool"
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF", "-.inf", "-.Inf", "-.INF", ".nan", ".NaN", ".NAN":
+		return "float"
+	}
+
+	if intPattern.MatchString(text) {
+		return "int"
+	}
+	if floatPattern.MatchString(text) {
+		return "float"
+	}
+	return "str"
+}
+
+// Type returns the type of a scalar as resolved by the YAML core schema:
+// null, bool, int, float or str.
+func (n *Node) Type() string {
+	if n.String {
+		return "str"
+	}
+	return Resolve(n.Value)
+}
+
+func (n *Node) errorf(format string, args ...interface{}) error {
+	if n.Line > 0 {
+		format = "line %d: " + format
+		args = append([]interface{}{n.Line}, args...)
+	}
+	return fmt.Errorf(format, args...)
+}
+
+func (n *Node) appendJSON(buf *bytes.Buffer) error {
+	switch n.Kind {
+	case MappingNode:
+		buf.WriteByte('{')
+		for i, key := range n.Keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			data, _ := json.Marshal(key)
+			buf.Write(data)
+			buf.WriteByte(':')
+			err := n.Items[i].appendJSON(buf)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+
+	case SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.Items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := item.appendJSON(buf)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	switch n.Type() {
+	case "null":
+		buf.WriteString("null")
+
+	case "bool":
+		buf.WriteString(strings.ToLower(n.Value))
+
+	case "int":
+		if jsonNumber.MatchString(n.Value) {
+			buf.WriteString(n.Value)
+			break
+		}
+		digits := strings.TrimLeft(strings.TrimLeft(n.Value, "+-"), "0")
+		if digits == "" {
+			digits = "0"
+		}
+		if strings.HasPrefix(n.Value, "-") && digits != "0" {
+			buf.WriteByte('-')
+		}
+		buf.WriteString(digits)
+
+	case "float":
+		if jsonNumber.MatchString(n.Value) {
+			buf.WriteString(n.Value)
+			break
+		}
+		value, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil || value != value || value > 1e308 || value < -1e308 {
+			return n.errorf("%s cannot be represented in JSON", n.Value)
+		}
+		buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+
+	default:
+		data, _ := json.Marshal(n.Value)
+		buf.Write(data)
+	}
+
+	return nil
+}
+
+// JSON encodes a node as JSON. Tags are ignored.
+func (n *Node) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	err := n.appendJSON(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fromJSON(decoder *json.Decoder) (*Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			n := NewSequence()
+			for decoder.More() {
+				item, err := fromJSON(decoder)
+				if err != nil {
+					return nil, err
+				}
+				n.Items = append(n.Items, item)
+			}
+			_, err = decoder.Token()
+			return n, err
+		}
+
+		n := NewMapping()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			n.Append(key.(string), value)
+		}
+		_, err = decoder.Token()
+		return n, err
+
+	case string:
+		return NewString(t), nil
+	case json.Number:
+		return NewScalar(t.String()), nil
+	case bool:
+		return NewScalar(strconv.FormatBool(t)), nil
+	}
+
+	return NewScalar("null"), nil
+}
+
+// FromJSON converts a JSON document to a node, keeping the order of object
+// keys.
+func FromJSON(data []byte) (*Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return fromJSON(decoder)
+}
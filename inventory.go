@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant/profile"
+	"strings"
+	"text/tabwriter"
+)
+
+func runInventory(args []string) error {
+	flags := flag.NewFlagSet("inventory", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print characters as JSON")
+	category := flags.String("category", "", "only list items of this category in the name database (implies -names)")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision inventory [-json] [-names] [-category Trait] profile.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one profile save")
+	}
+
+	if *category != "" {
+		*nameFlags.enabled = true
+	}
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	characters, err := profile.ReadCharacters(&archive)
+	if err != nil {
+		return err
+	}
+
+	if *category != "" {
+		for i := range characters {
+			items := []profile.Item{}
+			for _, item := range characters[i].Items {
+				if strings.EqualFold(displayNames.Category(item.BlueprintPath), *category) {
+					items = append(items, item)
+				}
+			}
+			characters[i].Items = items
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(characters)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHARACTER\tITEM\tCATEGORY\tQUANTITY\tLEVEL\tNEW\tFAVORITE\tSLOT")
+	for _, character := range characters {
+		for _, item := range character.Items {
+			slot := "-"
+			if item.Equipped {
+				slot = fmt.Sprint(item.EquipmentSlot)
+			}
+			itemCategory := displayNames.Category(item.BlueprintPath)
+			if itemCategory == "" {
+				itemCategory = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%t\t%t\t%s\n",
+				character.Index, displayNames.DisplayName(item.BlueprintPath, item.BlueprintPath), itemCategory, item.Quantity, item.Level, item.New, item.Favorite, slot)
+		}
+	}
+	return w.Flush()
+}
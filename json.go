@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant"
+)
+
+func runJSON(args []string) error {
+	flags := flag.NewFlagSet("json", flag.ExitOnError)
+	compact := flags.Bool("compact", false, "write compact instead of indented JSON")
+	lines := flags.Bool("ndjson", false, "write newline delimited JSON, one line per object and actor")
+	pretty := flags.Bool("pretty", false, "decode dates, durations, GUIDs, enums, rotations and object references for reading; the output cannot be imported")
+	output := flags.String("o", "", "output file (default: standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision json [-compact] [-ndjson] [-pretty] [-o file] save.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one save file")
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	file := os.Stdout
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+	}
+
+	encoder := remnant.NewJSONEncoder(file)
+	if !*compact {
+		encoder.SetIndent("  ")
+	}
+	encoder.SetPretty(*pretty)
+	if *lines {
+		err = encoder.EncodeLines(&archive)
+	} else {
+		err = encoder.Encode(&archive)
+	}
+
+	if file == os.Stdout {
+		return err
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/names"
+	"revision-go/remnant"
+	"revision-go/remnant/profile"
+	"text/tabwriter"
+)
+
+func runLs(args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the index as JSON")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision ls [-json] [-names] [dir]")
+		fmt.Fprintln(flags.Output(), "Without dir, the Steam save locations of this system are listed.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	var dirs []string
+	switch flags.NArg() {
+	case 0:
+		dirs = remnant.FindSaveDirs()
+		if len(dirs) == 0 {
+			return fmt.Errorf("no save directories found, pass one explicitly")
+		}
+	case 1:
+		dirs = []string{flags.Arg(0)}
+	default:
+		flags.Usage()
+		return fmt.Errorf("expected at most one directory")
+	}
+
+	saveDirs := make([]*remnant.SaveDir, 0, len(dirs))
+	for _, dir := range dirs {
+		saveDir, err := remnant.OpenSaveDir(dir)
+		if err != nil {
+			return err
+		}
+		saveDirs = append(saveDirs, saveDir)
+	}
+
+	if *asJSON {
+		// the parsed profile is left out, it is available through the dump
+		type saveDirIndex struct {
+			Dir          string
+			ProfilePath  string
+			ProfileError string
+			Slots        []remnant.SaveSlot
+		}
+		index := make([]saveDirIndex, len(saveDirs))
+		for i, saveDir := range saveDirs {
+			index[i] = saveDirIndex{
+				Dir:          saveDir.Dir,
+				ProfilePath:  saveDir.ProfilePath,
+				ProfileError: saveDir.ProfileError,
+				Slots:        saveDir.Slots,
+			}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(index)
+	}
+
+	for _, saveDir := range saveDirs {
+		printSaveDir(saveDir, displayNames)
+	}
+
+	return nil
+}
+
+// primaryArchetypes returns the primary archetype of every character of the
+// profile by slot.
+func primaryArchetypes(saveDir *remnant.SaveDir) map[int]string {
+	archetypes := map[int]string{}
+	if saveDir.Profile == nil {
+		return archetypes
+	}
+
+	characters, err := profile.ReadCharacters(saveDir.Profile)
+	if err != nil {
+		return archetypes
+	}
+	for _, character := range characters {
+		archetypes[character.Index] = character.Build.PrimaryArchetype
+	}
+	return archetypes
+}
+
+func printSaveDir(saveDir *remnant.SaveDir, displayNames *names.Database) {
+	fmt.Println(saveDir.Dir)
+	switch {
+	case saveDir.ProfilePath == "":
+		fmt.Println("  no profile")
+	case saveDir.ProfileError != "":
+		fmt.Printf("  profile: %s\n", saveDir.ProfileError)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	archetypes := primaryArchetypes(saveDir)
+	fmt.Fprintln(w, "  SLOT\tFILE\tCHARACTER\tARCHETYPE\tBUILD\tSIZE\tMODIFIED")
+	for _, slot := range saveDir.Slots {
+		character := slot.CharacterObjectPath
+		if character == "" {
+			character = "-"
+		}
+		archetype := "-"
+		if path := archetypes[slot.Index]; path != "" {
+			archetype = displayName(displayNames, path)
+		}
+		build := fmt.Sprint(slot.Header.BuildNumber)
+		if slot.Error != "" {
+			build = "error: " + slot.Error
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			slot.Index, filepath.Base(slot.Path), character, archetype, build, slot.Size, slot.ModTime.Format("2006-01-02 15:04:05"))
+	}
+	w.Flush()
+}
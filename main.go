@@ -1,24 +1,87 @@
 package main
 
 import (
-	"bytes"
+	"fmt"
 	"log"
 	"os"
-	"revision-go/config"
+	"path/filepath"
 	"revision-go/remnant"
 	"revision-go/utils"
+	"strings"
 )
 
+var commands = map[string]func(args []string) error{
+	"import":    runImport,
+	"diff":      runDiff,
+	"patch":     runPatch,
+	"inventory": runInventory,
+	"build":     runBuild,
+	"world":     runWorld,
+	"checklist": runChecklist,
+	"ls":        runLs,
+	"backup":    runBackup,
+	"watch":     runWatch,
+	"serve":     runServe,
+	"hexmap":    runHexmap,
+	"coverage":  runCoverage,
+	"schema":    runSchema,
+	"codegen":   runCodegen,
+	"json":      runJSON,
+	"export":    runExport,
+	"yaml":      runYAML,
+	"browse":    runBrowse,
+	"stats":     runStats,
+}
+
+func loadSave(path string) (remnant.SaveArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return remnant.SaveArchive{}, err
+	}
+
+	archive, err := remnant.DecodeSave(data)
+	if err != nil {
+		return remnant.SaveArchive{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return archive, nil
+}
+
 func main() {
-	fileData, err := remnant.ReadData(os.Args[1])
+	if len(os.Args) < 2 {
+		log.Fatal("usage: revision <file.sav> | revision <command> [arguments]")
+	}
+
+	if command, ok := commands[os.Args[1]]; ok {
+		err := command(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	result, err := loadSave(os.Args[1])
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	result, err := remnant.ReadSaveArchive(bytes.NewReader(fileData))
+	name := filepath.Base(os.Args[1])
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	file, err := utils.CreateJSONFile(name, name+"_processed")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	utils.SaveToFile(config.INPUT_FILE_NAME_WITHOUT_EXTENSION, config.INPUT_FILE_NAME_WITHOUT_EXTENSION+"_processed", "json", result)
+	// the document is streamed instead of being built in memory
+	encoder := remnant.NewJSONEncoder(file)
+	encoder.SetIndent("  ")
+	err = encoder.Encode(&result)
+	if err != nil {
+		file.Close()
+		log.Fatal(err)
+	}
+	err = file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,7 +1,9 @@
 package memory
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 )
 
@@ -17,3 +19,26 @@ func ReadInt[T Int](r io.Reader) (T, error) {
 	}
 	return value, nil
 }
+
+func WriteInt[T Int](w io.Writer, value T) error {
+	return binary.Write(w, binary.LittleEndian, value)
+}
+
+// ReadBytes reads exactly n bytes. The result grows with the data actually
+// read, so a corrupt length read from a file does not allocate more memory
+// than the reader holds.
+func ReadBytes(r io.Reader, n int64) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+
+	var buf bytes.Buffer
+	_, err := io.CopyN(&buf, r, n)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"revision-go/names"
+)
+
+type nameOptions struct {
+	enabled *bool
+	file    *string
+}
+
+func addNameFlags(flags *flag.FlagSet) *nameOptions {
+	return &nameOptions{
+		enabled: flags.Bool("names", false, "show display names from the name database"),
+		file:    flags.String("names-file", "", "JSON file extending the name database (implies -names)"),
+	}
+}
+
+// userNamesFile is loaded on top of the embedded names when it exists.
+func userNamesFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "revision", "names.json")
+}
+
+// database returns the embedded names extended by the user's names file and
+// -names-file, or nil if display names are not requested.
+func (o *nameOptions) database() (*names.Database, error) {
+	if !*o.enabled && *o.file == "" {
+		return nil, nil
+	}
+
+	database, err := names.Embedded()
+	if err != nil {
+		return nil, err
+	}
+
+	if path := userNamesFile(); path != "" {
+		err = database.LoadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if *o.file != "" {
+		err = database.LoadFile(*o.file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return database, nil
+}
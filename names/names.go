@@ -0,0 +1,127 @@
+// Package names maps asset paths and enum values found in saves to display
+// names and categories. A default table is embedded in the binary; users can
+// extend or override it with JSON files in the same format.
+package names
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"revision-go/ue"
+	"strings"
+)
+
+//go:embed names.json
+var embeddedNames []byte
+
+type Entry struct {
+	Name     string
+	Category string
+}
+
+// Database holds entries keyed by full asset path, asset name (the last path
+// element without the object name, e.g. Archetype_Handler) or enum value.
+type Database struct {
+	entries map[string]Entry
+}
+
+func New() *Database {
+	return &Database{
+		entries: map[string]Entry{},
+	}
+}
+
+// Embedded returns a database with the entries shipped with the binary.
+func Embedded() (*Database, error) {
+	database := New()
+
+	err := database.Load(bytes.NewReader(embeddedNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedded names: %w", err)
+	}
+
+	return database, nil
+}
+
+// Load adds the entries of a JSON object mapping keys to entries. Entries
+// replace existing ones with the same key.
+func (d *Database) Load(r io.Reader) error {
+	entries := map[string]Entry{}
+
+	err := json.NewDecoder(r).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	for key, entry := range entries {
+		d.entries[key] = entry
+	}
+
+	return nil
+}
+
+func (d *Database) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = d.Load(file)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Lookup finds the entry for an asset path or enum value. Asset paths match
+// entries for the full path, the path without the object name and the asset
+// name, in that order.
+func (d *Database) Lookup(key string) (Entry, bool) {
+	if entry, ok := d.entries[key]; ok {
+		return entry, true
+	}
+
+	if !strings.Contains(key, "/") {
+		return Entry{}, false
+	}
+
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		if entry, ok := d.entries[key[:i]]; ok {
+			return entry, true
+		}
+	}
+
+	entry, ok := d.entries[ue.AssetName(key)]
+	return entry, ok
+}
+
+// DisplayName returns the display name for key, or fallback if there is none.
+// A nil database has no entries.
+func (d *Database) DisplayName(key string, fallback string) string {
+	if d == nil {
+		return fallback
+	}
+
+	entry, ok := d.Lookup(key)
+	if !ok || entry.Name == "" {
+		return fallback
+	}
+
+	return entry.Name
+}
+
+// Category returns the category of the entry for key, empty if there is no
+// entry or it has no category. A nil database has no entries.
+func (d *Database) Category(key string) string {
+	if d == nil {
+		return ""
+	}
+
+	entry, _ := d.Lookup(key)
+	return entry.Category
+}
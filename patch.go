@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"revision-go/remnant"
+	"strings"
+)
+
+func runPatch(args []string) error {
+	flags := flag.NewFlagSet("patch", flag.ExitOnError)
+	output := flags.String("o", "", "output save file (default: input name with _patched suffix)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision patch [-o patched.sav] save.sav patch.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a save file and a patch file")
+	}
+	savePath := flags.Arg(0)
+	patchPath := flags.Arg(1)
+
+	outputPath := *output
+	if outputPath == "" {
+		extension := filepath.Ext(savePath)
+		outputPath = strings.TrimSuffix(savePath, extension) + "_patched" + extension
+	}
+
+	file, err := os.Open(patchPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	operations, err := remnant.ReadPatch(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", patchPath, err)
+	}
+
+	archive, err := loadSave(savePath)
+	if err != nil {
+		return err
+	}
+
+	err = remnant.ApplyPatch(&archive, operations)
+	if err != nil {
+		return fmt.Errorf("patch not applied: %w", err)
+	}
+
+	var data bytes.Buffer
+	err = remnant.WriteSaveArchive(&data, &archive)
+	if err != nil {
+		return fmt.Errorf("failed to encode save: %w", err)
+	}
+
+	return remnant.WriteData(outputPath, data.Bytes())
+}
@@ -0,0 +1,178 @@
+package remnant
+
+import (
+	"encoding/hex"
+	"sort"
+)
+
+// UnreadSample is a distinct byte pattern left unread, truncated to
+// MaxSampleLength bytes.
+type UnreadSample struct {
+	Data  string
+	Count int
+}
+
+// UnreadGroup is the data left unread for one component key, object path or
+// property type.
+type UnreadGroup struct {
+	Key         string
+	Occurrences int
+	Files       int
+	Bytes       int
+	Samples     []UnreadSample
+
+	files map[string]bool
+}
+
+type CoverageError struct {
+	File  string
+	Error string
+}
+
+// CoverageReport lists the groups by the number of unread bytes, largest first.
+type CoverageReport struct {
+	Files         int
+	Bytes         int
+	UnreadBytes   int
+	Errors        []CoverageError
+	ComponentKeys []UnreadGroup
+	ObjectPaths   []UnreadGroup
+	// type of the last property read before the unread data, which is the
+	// usual suspect when a reader stops early
+	PropertyTypes []UnreadGroup
+}
+
+const MaxSampleLength = 32
+
+// Coverage aggregates the data that readObjectData and readComponents could not
+// parse across many saves.
+type Coverage struct {
+	maxSamples int
+
+	files         int
+	bytes         int
+	unreadBytes   int
+	errors        []CoverageError
+	componentKeys map[string]*UnreadGroup
+	objectPaths   map[string]*UnreadGroup
+	propertyTypes map[string]*UnreadGroup
+}
+
+// NewCoverage returns an empty coverage that keeps up to maxSamples byte
+// patterns per group.
+func NewCoverage(maxSamples int) *Coverage {
+	return &Coverage{
+		maxSamples:    maxSamples,
+		errors:        []CoverageError{},
+		componentKeys: map[string]*UnreadGroup{},
+		objectPaths:   map[string]*UnreadGroup{},
+		propertyTypes: map[string]*UnreadGroup{},
+	}
+}
+
+func (c *Coverage) add(groups map[string]*UnreadGroup, key string, file string, data []byte) {
+	group, ok := groups[key]
+	if !ok {
+		group = &UnreadGroup{Key: key, files: map[string]bool{}}
+		groups[key] = group
+	}
+
+	group.Occurrences++
+	group.Bytes += len(data)
+	if !group.files[file] {
+		group.files[file] = true
+		group.Files++
+	}
+
+	if len(data) > MaxSampleLength {
+		data = data[:MaxSampleLength]
+	}
+	sample := hex.EncodeToString(data)
+	for i := range group.Samples {
+		if group.Samples[i].Data == sample {
+			group.Samples[i].Count++
+			return
+		}
+	}
+	if len(group.Samples) < c.maxSamples {
+		group.Samples = append(group.Samples, UnreadSample{Data: sample, Count: 1})
+	}
+}
+
+func lastPropertyType(properties []Property) string {
+	if len(properties) == 0 {
+		return "(none)"
+	}
+	return properties[len(properties)-1].Type
+}
+
+func (c *Coverage) addUnread(file string, componentKey string, objectPath string, properties []Property, data []byte) {
+	c.unreadBytes += len(data)
+	if componentKey != "" {
+		c.add(c.componentKeys, componentKey, file, data)
+	}
+	c.add(c.objectPaths, objectPath, file, data)
+	c.add(c.propertyTypes, lastPropertyType(properties), file, data)
+}
+
+// Add records the unread data of a parsed save, including the archives nested
+// in it. size is the size of the decompressed save data.
+func (c *Coverage) Add(file string, size int, archive *SaveArchive) {
+	c.files++
+	c.bytes += size
+
+	objects := map[string]*UObject{}
+	Walk(archive, func(path Path, node Node) error {
+		switch n := node.(type) {
+		case *UObject:
+			objects[path.String()] = n
+			if len(n.UnreadData) > 0 {
+				c.addUnread(file, "", n.ObjectPath, n.Properties, n.UnreadData)
+			}
+
+		case *Component:
+			if len(n.UnreadData) > 0 {
+				objectPath := ""
+				if object, ok := objects[path[:len(path)-2].String()]; ok {
+					objectPath = object.ObjectPath
+				}
+				c.addUnread(file, n.ComponentKey, objectPath, n.Properties, n.UnreadData)
+			}
+		}
+		return nil
+	})
+}
+
+// AddError records a save that could not be parsed.
+func (c *Coverage) AddError(file string, err error) {
+	c.files++
+	c.errors = append(c.errors, CoverageError{File: file, Error: err.Error()})
+}
+
+func sortedGroups(groups map[string]*UnreadGroup) []UnreadGroup {
+	result := make([]UnreadGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+func (c *Coverage) Report() CoverageReport {
+	return CoverageReport{
+		Files:         c.files,
+		Bytes:         c.bytes,
+		UnreadBytes:   c.unreadBytes,
+		Errors:        c.errors,
+		ComponentKeys: sortedGroups(c.componentKeys),
+		ObjectPaths:   sortedGroups(c.objectPaths),
+		PropertyTypes: sortedGroups(c.propertyTypes),
+	}
+}
@@ -0,0 +1,320 @@
+package remnant
+
+import (
+	"reflect"
+	"strconv"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Change is a single difference between two archives. Path addresses the node
+// in the new archive, or in the old one for removed nodes.
+type Change struct {
+	Type ChangeType
+	Path Path
+	Old  interface{}
+	New  interface{}
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) added(path Path, value interface{}) {
+	d.changes = append(d.changes, Change{Type: ChangeAdded, Path: path, New: value})
+}
+
+func (d *differ) removed(path Path, value interface{}) {
+	d.changes = append(d.changes, Change{Type: ChangeRemoved, Path: path, Old: value})
+}
+
+func (d *differ) changed(path Path, oldValue interface{}, newValue interface{}) {
+	d.changes = append(d.changes, Change{Type: ChangeChanged, Path: path, Old: oldValue, New: newValue})
+}
+
+// valuesEqual reports whether two values are the same. Object ids differ
+// between saves, so references are compared by class name.
+func valuesEqual(oldValue interface{}, newValue interface{}) bool {
+	oldObject, isOldObject := oldValue.(ObjectProperty)
+	newObject, isNewObject := newValue.(ObjectProperty)
+	if isOldObject && isNewObject {
+		return oldObject.ClassName == newObject.ClassName
+	}
+
+	return reflect.DeepEqual(oldValue, newValue)
+}
+
+func (d *differ) compareValues(path Path, oldValue interface{}, newValue interface{}) {
+	if !valuesEqual(oldValue, newValue) {
+		d.changed(path, oldValue, newValue)
+	}
+}
+
+// Diff compares two archives. Objects are aligned by ObjectPath, actors by
+// UniqueID, properties by name and index, array items by position and map
+// entries by key, with object references as keys matched by class name.
+// Sizes, offsets and the names table are not compared.
+func Diff(oldArchive *SaveArchive, newArchive *SaveArchive) []Change {
+	d := differ{}
+	d.compareSaveData(Path{}, Path{}, &oldArchive.Data, &newArchive.Data)
+	return d.changes
+}
+
+func (d *differ) compareSaveData(oldPath Path, newPath Path, oldData *SaveData, newData *SaveData) {
+	// objects with the same path are paired in order of appearance
+	oldObjects := map[string][]int{}
+	for i := range oldData.Objects {
+		objectPath := oldData.Objects[i].ObjectPath
+		oldObjects[objectPath] = append(oldObjects[objectPath], i)
+	}
+
+	matched := make([]bool, len(oldData.Objects))
+	for i := range newData.Objects {
+		newObject := &newData.Objects[i]
+		objectNewPath := newPath.Append("Objects", strconv.Itoa(i))
+
+		candidates := oldObjects[newObject.ObjectPath]
+		if len(candidates) == 0 {
+			d.added(objectNewPath, newObject.ObjectPath)
+			continue
+		}
+		oldObjects[newObject.ObjectPath] = candidates[1:]
+		matched[candidates[0]] = true
+
+		objectOldPath := oldPath.Append("Objects", strconv.Itoa(candidates[0]))
+		d.compareObject(objectOldPath, objectNewPath, &oldData.Objects[candidates[0]], newObject)
+	}
+
+	for i := range oldData.Objects {
+		if !matched[i] {
+			d.removed(oldPath.Append("Objects", strconv.Itoa(i)), oldData.Objects[i].ObjectPath)
+		}
+	}
+}
+
+func (d *differ) compareObject(oldPath Path, newPath Path, oldObject *UObject, newObject *UObject) {
+	d.compareProperties(oldPath.Append("Properties"), newPath.Append("Properties"), oldObject.Properties, newObject.Properties)
+
+	oldComponents := map[string]int{}
+	for i := range oldObject.Components {
+		oldComponents[oldObject.Components[i].ComponentKey] = i
+	}
+
+	seen := map[string]bool{}
+	for i := range newObject.Components {
+		newComponent := &newObject.Components[i]
+		componentNewPath := newPath.Append("Components", newComponent.ComponentKey)
+		seen[newComponent.ComponentKey] = true
+
+		j, ok := oldComponents[newComponent.ComponentKey]
+		if !ok {
+			d.added(componentNewPath, newComponent.Properties)
+			continue
+		}
+
+		oldComponent := &oldObject.Components[j]
+		componentOldPath := oldPath.Append("Components", oldComponent.ComponentKey)
+		d.compareProperties(componentOldPath, componentNewPath, oldComponent.Properties, newComponent.Properties)
+		d.compareValues(componentNewPath.Append("UnreadData"), oldComponent.UnreadData, newComponent.UnreadData)
+	}
+
+	for i := range oldObject.Components {
+		if !seen[oldObject.Components[i].ComponentKey] {
+			d.removed(oldPath.Append("Components", oldObject.Components[i].ComponentKey), oldObject.Components[i].Properties)
+		}
+	}
+
+	d.compareValues(newPath.Append("UnreadData"), oldObject.UnreadData, newObject.UnreadData)
+}
+
+func (d *differ) compareProperties(oldPath Path, newPath Path, oldProperties []Property, newProperties []Property) {
+	oldIndex := map[string]int{}
+	for i := range oldProperties {
+		oldIndex[propertyKey(&oldProperties[i])] = i
+	}
+
+	seen := map[string]bool{}
+	for i := range newProperties {
+		newProperty := &newProperties[i]
+		key := propertyKey(newProperty)
+		seen[key] = true
+
+		j, ok := oldIndex[key]
+		if !ok {
+			d.added(newPath.Append(key), newProperty.Value)
+			continue
+		}
+
+		d.compareProperty(oldPath.Append(key), newPath.Append(key), &oldProperties[j], newProperty)
+	}
+
+	for i := range oldProperties {
+		key := propertyKey(&oldProperties[i])
+		if !seen[key] {
+			d.removed(oldPath.Append(key), oldProperties[i].Value)
+		}
+	}
+}
+
+func (d *differ) compareProperty(oldPath Path, newPath Path, oldProperty *Property, newProperty *Property) {
+	if oldProperty.Type != newProperty.Type {
+		d.changed(newPath, oldProperty.Value, newProperty.Value)
+		return
+	}
+
+	switch newValue := newProperty.Value.(type) {
+	case StructProperty:
+		oldValue, ok := oldProperty.Value.(StructProperty)
+		if !ok || oldValue.Name != newValue.Name {
+			break
+		}
+		d.compareStructValue(oldPath, newPath, oldValue.Value, newValue.Value)
+		return
+
+	case ArrayStructProperty:
+		oldValue, ok := oldProperty.Value.(ArrayStructProperty)
+		if !ok || oldValue.ElementType != newValue.ElementType {
+			break
+		}
+		for i := range newValue.Items {
+			itemPath := newPath.Append(strconv.Itoa(i))
+			if i >= len(oldValue.Items) {
+				d.added(itemPath, newValue.Items[i].Value)
+				continue
+			}
+			d.compareStructValue(oldPath.Append(strconv.Itoa(i)), itemPath, oldValue.Items[i].Value, newValue.Items[i].Value)
+		}
+		for i := len(newValue.Items); i < len(oldValue.Items); i++ {
+			d.removed(oldPath.Append(strconv.Itoa(i)), oldValue.Items[i].Value)
+		}
+		return
+
+	case ArrayProperty:
+		oldValue, ok := oldProperty.Value.(ArrayProperty)
+		if !ok || oldValue.ElementType != newValue.ElementType {
+			break
+		}
+		for i := range newValue.Items {
+			itemPath := newPath.Append(strconv.Itoa(i))
+			if i >= len(oldValue.Items) {
+				d.added(itemPath, newValue.Items[i])
+				continue
+			}
+			d.compareValues(itemPath, oldValue.Items[i], newValue.Items[i])
+		}
+		for i := len(newValue.Items); i < len(oldValue.Items); i++ {
+			d.removed(oldPath.Append(strconv.Itoa(i)), oldValue.Items[i])
+		}
+		return
+
+	case MapProperty:
+		oldValue, ok := oldProperty.Value.(MapProperty)
+		if !ok {
+			break
+		}
+		d.compareMap(oldPath, newPath, oldValue, newValue)
+		return
+
+	case Variables:
+		oldValue, ok := oldProperty.Value.(Variables)
+		if !ok {
+			break
+		}
+		d.compareProperties(oldPath, newPath, oldValue.Properties, newValue.Properties)
+		return
+	}
+
+	d.compareValues(newPath, oldProperty.Value, newProperty.Value)
+}
+
+func (d *differ) compareMap(oldPath Path, newPath Path, oldValue MapProperty, newValue MapProperty) {
+	matched := make([]bool, len(oldValue.Values))
+
+	for i, newEntry := range newValue.Values {
+		entryPath := newPath.Append(strconv.Itoa(i))
+
+		found := false
+		for j, oldEntry := range oldValue.Values {
+			if matched[j] || !valuesEqual(oldEntry.Key, newEntry.Key) {
+				continue
+			}
+			matched[j] = true
+			found = true
+			d.compareValues(entryPath, oldEntry.Value, newEntry.Value)
+			break
+		}
+		if !found {
+			d.added(entryPath, newEntry)
+		}
+	}
+
+	for j, oldEntry := range oldValue.Values {
+		if !matched[j] {
+			d.removed(oldPath.Append(strconv.Itoa(j)), oldEntry)
+		}
+	}
+}
+
+func (d *differ) compareStructValue(oldPath Path, newPath Path, oldValue interface{}, newValue interface{}) {
+	switch newStruct := newValue.(type) {
+	case []Property:
+		oldStruct, ok := oldValue.([]Property)
+		if !ok {
+			break
+		}
+		d.compareProperties(oldPath, newPath, oldStruct, newStruct)
+		return
+
+	case PersistenceBlob:
+		oldStruct, ok := oldValue.(PersistenceBlob)
+		if !ok {
+			break
+		}
+		d.compareSaveData(oldPath.Append("Archive"), newPath.Append("Archive"), &oldStruct.Archive, &newStruct.Archive)
+		return
+
+	case PersistenceContainer:
+		oldStruct, ok := oldValue.(PersistenceContainer)
+		if !ok {
+			break
+		}
+		d.compareContainer(oldPath, newPath, &oldStruct, &newStruct)
+		return
+	}
+
+	d.compareValues(newPath, oldValue, newValue)
+}
+
+func (d *differ) compareContainer(oldPath Path, newPath Path, oldContainer *PersistenceContainer, newContainer *PersistenceContainer) {
+	d.compareValues(newPath.Append("Version"), oldContainer.Version, newContainer.Version)
+	d.compareValues(newPath.Append("Destroyed"), oldContainer.Destroyed, newContainer.Destroyed)
+
+	for _, id := range sortedActorIDs(newContainer.Actors) {
+		actorNewPath := newPath.Append("Actors", strconv.FormatUint(id, 10))
+		newActor := newContainer.Actors[id]
+
+		oldActor, ok := oldContainer.Actors[id]
+		if !ok {
+			d.added(actorNewPath, newActor)
+			continue
+		}
+
+		actorOldPath := oldPath.Append("Actors", strconv.FormatUint(id, 10))
+		d.compareValues(actorNewPath.Append("Transform"), oldActor.Transform, newActor.Transform)
+		d.compareValues(actorNewPath.Append("DynamicData"), oldActor.DynamicData, newActor.DynamicData)
+		d.compareValues(actorNewPath.Append("DynamicOnly"), oldActor.DynamicOnly, newActor.DynamicOnly)
+		d.compareSaveData(actorOldPath.Append("Archive"), actorNewPath.Append("Archive"), &oldActor.Archive, &newActor.Archive)
+	}
+
+	for _, id := range sortedActorIDs(oldContainer.Actors) {
+		if _, ok := newContainer.Actors[id]; !ok {
+			d.removed(oldPath.Append("Actors", strconv.FormatUint(id, 10)), oldContainer.Actors[id])
+		}
+	}
+}
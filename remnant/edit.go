@@ -0,0 +1,700 @@
+package remnant
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"revision-go/ue"
+)
+
+// checkValue verifies that value has the Go type getPropertyValue produces for
+// varType, so that an edited property is written in a form the reader accepts.
+func checkValue(varType string, value interface{}, saveData *SaveData, raw bool) error {
+	var ok bool
+
+	switch varType {
+	case "IntProperty":
+		_, ok = value.(int32)
+	case "Int16Property":
+		_, ok = value.(int16)
+	case "Int64Property":
+		_, ok = value.(int64)
+	case "UInt64Property":
+		_, ok = value.(uint64)
+	case "FloatProperty", "FloatProeprty":
+		_, ok = value.(float32)
+	case "DoubleProperty":
+		_, ok = value.(float64)
+	case "UInt16Property":
+		_, ok = value.(uint16)
+	case "UInt32Property":
+		_, ok = value.(uint32)
+	case "SoftClassPath", "SoftObjectProperty", "StrProperty", "NameProperty":
+		_, ok = value.(string)
+	case "BoolProperty":
+		_, ok = value.(bool)
+
+	case "MapProperty":
+		mapProperty, isMap := value.(MapProperty)
+		if !isMap || raw {
+			break
+		}
+		for _, entry := range mapProperty.Values {
+			err := checkValue(mapProperty.KeyType, entry.Key, saveData, true)
+			if err != nil {
+				return fmt.Errorf("map key: %w", err)
+			}
+			err = checkValue(mapProperty.ValueType, entry.Value, saveData, true)
+			if err != nil {
+				return fmt.Errorf("map value: %w", err)
+			}
+		}
+		return nil
+
+	case "EnumProperty":
+		_, ok = value.(EnumProperty)
+
+	case "TextProperty":
+		textProperty, isText := value.(TextProperty)
+		if !isText {
+			break
+		}
+		switch textProperty.HistoryType {
+		case 0:
+			_, ok = textProperty.Data.(TextPropertyData)
+		case 255:
+			_, ok = textProperty.Data.(TextData)
+			ok = ok || textProperty.Data == nil
+		default:
+			return fmt.Errorf("unsupported text history type %d", textProperty.HistoryType)
+		}
+
+	case "ArrayProperty":
+		switch v := value.(type) {
+		case ArrayStructProperty:
+			if v.Count != uint32(len(v.Items)) {
+				return fmt.Errorf("array count %d does not match %d items", v.Count, len(v.Items))
+			}
+			for i, item := range v.Items {
+				err := checkArrayStructItem(v, item, saveData)
+				if err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+			return nil
+
+		case ArrayProperty:
+			if v.ElementType == "StructProperty" {
+				return fmt.Errorf("arrays of structs must be stored as ArrayStructProperty")
+			}
+			if v.Count != uint32(len(v.Items)) {
+				return fmt.Errorf("array count %d does not match %d items", v.Count, len(v.Items))
+			}
+			for i, item := range v.Items {
+				err := checkValue(v.ElementType, item, saveData, true)
+				if err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+			return nil
+		}
+
+	case "StructProperty":
+		if raw {
+			_, ok = value.(StructReference)
+			break
+		}
+		structProperty, isStruct := value.(StructProperty)
+		if !isStruct {
+			break
+		}
+		return checkStructValue(structProperty.Name, structProperty.Value, saveData)
+
+	case "ObjectProperty":
+		objectProperty, isObject := value.(ObjectProperty)
+		if !isObject {
+			break
+		}
+		if objectProperty.ObjectID == -1 {
+			if objectProperty.ClassName != "" {
+				return fmt.Errorf("empty object reference with class name %q", objectProperty.ClassName)
+			}
+			return nil
+		}
+		if objectProperty.ObjectID < 0 || int(objectProperty.ObjectID) >= len(saveData.Objects) {
+			return fmt.Errorf("object %d does not exist", objectProperty.ObjectID)
+		}
+		if path := saveData.Objects[objectProperty.ObjectID].ObjectPath; path != objectProperty.ClassName {
+			return fmt.Errorf("object %d is %q, not %q", objectProperty.ObjectID, path, objectProperty.ClassName)
+		}
+		return nil
+
+	case "ByteProperty":
+		_, ok = value.(uint8)
+		if !raw && !ok {
+			_, ok = value.(EnumProperty)
+		}
+
+	case "None":
+		ok = value == nil
+
+	default:
+		return fmt.Errorf("property type is not supported yet: %s", varType)
+	}
+
+	if !ok {
+		return fmt.Errorf("unexpected value %T for %s", value, varType)
+	}
+
+	return nil
+}
+
+func checkArrayStructItem(array ArrayStructProperty, item StructProperty, saveData *SaveData) error {
+	if item.Name != array.ElementType {
+		return fmt.Errorf("struct %s does not match array element type %s", item.Name, array.ElementType)
+	}
+	return checkStructValue(item.Name, item.Value, saveData)
+}
+
+func checkStructValue(structName string, value interface{}, saveData *SaveData) error {
+	var ok bool
+
+	switch structName {
+	case "SoftClassPath", "SoftObjectPath":
+		_, ok = value.(string)
+	case "Timespan", "DateTime":
+		_, ok = value.(int64)
+	case "Guid":
+		_, ok = value.(ue.FGuid)
+	case "Vector":
+		_, ok = value.(ue.FVector)
+
+	case "PersistenceBlob":
+		switch value.(type) {
+		case PersistenceBlob, PersistenceContainer:
+			ok = true
+		}
+
+	default:
+		properties, isProperties := value.([]Property)
+		if !isProperties {
+			break
+		}
+		for i := range properties {
+			err := checkProperty(&properties[i], saveData)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !ok {
+		return fmt.Errorf("unexpected value %T for struct %s", value, structName)
+	}
+
+	return nil
+}
+
+func checkProperty(property *Property, saveData *SaveData) error {
+	if property.Name == "FowVisitedCoordinates" {
+		data, ok := property.Value.([]byte)
+		if !ok || len(data) < 19 {
+			return fmt.Errorf("%s: unexpected value %T", property.Name, property.Value)
+		}
+		return nil
+	}
+
+	if variables, ok := property.Value.(Variables); ok {
+		for i := range variables.Properties {
+			err := checkProperty(&variables.Properties[i], saveData)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := checkValue(property.Type, property.Value, saveData, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", property.Name, err)
+	}
+
+	return nil
+}
+
+type resolvedPath struct {
+	chain []Node
+	edges []edge
+	store func()
+}
+
+func resolvePath(archive *SaveArchive, path Path) (*resolvedPath, error) {
+	chain, edges, store, err := resolve(&archive.Data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resolvedPath{
+		chain: chain,
+		edges: edges,
+		store: store,
+	}, nil
+}
+
+func (r *resolvedPath) property() (*Property, error) {
+	property, ok := r.chain[len(r.chain)-1].(*Property)
+	if !ok {
+		return nil, fmt.Errorf("not a property: %T", r.chain[len(r.chain)-1])
+	}
+	return property, nil
+}
+
+// saveData returns the archive that contains the node at the given position
+// of the chain.
+func (r *resolvedPath) saveData(position int) *SaveData {
+	for i := position; i >= 0; i-- {
+		if saveData, ok := r.chain[i].(*SaveData); ok {
+			return saveData
+		}
+	}
+	return nil
+}
+
+func isVariablesProperty(node Node) bool {
+	property, ok := node.(*Property)
+	if !ok {
+		return false
+	}
+	_, ok = property.Value.(Variables)
+	return ok
+}
+
+// nameTables holds the names table of every archive an edit touches, so that
+// it is built once per edit rather than for every property whose size is
+// recomputed.
+type nameTables map[*SaveData]*nameTable
+
+func (t nameTables) get(saveData *SaveData) *nameTable {
+	names, ok := t[saveData]
+	if !ok {
+		names = newNameTable(saveData.NamesTable)
+		t[saveData] = names
+	}
+	return names
+}
+
+// setPropertySize recomputes the Size of a tagged property and the sizes the
+// reader copies into its struct values.
+func setPropertySize(property *Property, names *nameTable) error {
+	_, size, err := propertyValueBytes(property, names)
+	if err != nil {
+		return err
+	}
+	property.Size = size
+
+	switch v := property.Value.(type) {
+	case StructProperty:
+		v.Size = size
+		property.Value = v
+
+	case ArrayStructProperty:
+		items := bytes.Buffer{}
+		for j := range v.Items {
+			err = writeStructPropertyData(&items, v.ElementType, v.Items[j].Value, names)
+			if err != nil {
+				return err
+			}
+			v.Items[j].Size = size
+		}
+		v.Size = uint32(items.Len())
+		property.Value = v
+	}
+
+	return nil
+}
+
+// setSizes recomputes the sizes of all tagged properties below and including
+// node, innermost first.
+func setSizes(node Node, saveData *SaveData, tagged bool, tables nameTables) error {
+	if data, ok := node.(*SaveData); ok {
+		saveData = data
+	}
+
+	edges, store := expand(node)
+	for _, e := range edges {
+		err := setSizes(e.node, saveData, tagged && !isVariablesProperty(node), tables)
+		if err != nil {
+			return err
+		}
+	}
+	store()
+
+	if property, ok := node.(*Property); ok && tagged && !isVariablesProperty(node) {
+		return setPropertySize(property, tables.get(saveData))
+	}
+
+	return nil
+}
+
+// updateSizes stores value-typed nodes back into the archive and recomputes
+// the sizes below the last node of the chain and of every property above it.
+func (r *resolvedPath) updateSizes() error {
+	r.store()
+
+	last := len(r.chain) - 1
+	tagged := true
+	for i := 0; i < last; i++ {
+		if isVariablesProperty(r.chain[i]) {
+			tagged = false
+		}
+	}
+
+	tables := nameTables{}
+	err := setSizes(r.chain[last], r.saveData(last), tagged, tables)
+	if err != nil {
+		return err
+	}
+
+	for i := last - 1; i > 0; i-- {
+		property, ok := r.chain[i].(*Property)
+		if !ok || isVariablesProperty(property) {
+			continue
+		}
+
+		err = setPropertySize(property, tables.get(r.saveData(i)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetProperty replaces the value of the property at path. The value must have
+// the same Go type the reader produces for the property type.
+func SetProperty(archive *SaveArchive, path Path, value interface{}) error {
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	property, err := resolved.property()
+	if err != nil {
+		return err
+	}
+
+	updated := *property
+	updated.Value = value
+	err = checkProperty(&updated, resolved.saveData(len(resolved.chain)-1))
+	if err != nil {
+		return err
+	}
+
+	property.Value = value
+	return resolved.updateSizes()
+}
+
+func arrayProperty(resolved *resolvedPath) (*Property, error) {
+	property, err := resolved.property()
+	if err != nil {
+		return nil, err
+	}
+
+	switch property.Value.(type) {
+	case ArrayProperty, ArrayStructProperty:
+		return property, nil
+	}
+
+	return nil, fmt.Errorf("%s is not an array: %s", property.Name, property.Type)
+}
+
+// AddArrayItem inserts value at index into the array property at path. An
+// index equal to the array length appends the value.
+func AddArrayItem(archive *SaveArchive, path Path, index int, value interface{}) error {
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	property, err := arrayProperty(resolved)
+	if err != nil {
+		return err
+	}
+	saveData := resolved.saveData(len(resolved.chain) - 1)
+
+	switch array := property.Value.(type) {
+	case ArrayStructProperty:
+		if index < 0 || index > len(array.Items) {
+			return fmt.Errorf("index %d out of range [0, %d]", index, len(array.Items))
+		}
+
+		item, ok := value.(StructProperty)
+		if !ok {
+			return fmt.Errorf("unexpected value %T for array of %s", value, array.ElementType)
+		}
+		err = checkArrayStructItem(array, item, saveData)
+		if err != nil {
+			return err
+		}
+		item.GUID = array.GUID
+
+		items := make([]StructProperty, 0, len(array.Items)+1)
+		items = append(items, array.Items[:index]...)
+		items = append(items, item)
+		array.Items = append(items, array.Items[index:]...)
+		array.Count = uint32(len(array.Items))
+		property.Value = array
+
+	case ArrayProperty:
+		if index < 0 || index > len(array.Items) {
+			return fmt.Errorf("index %d out of range [0, %d]", index, len(array.Items))
+		}
+
+		err = checkValue(array.ElementType, value, saveData, true)
+		if err != nil {
+			return err
+		}
+
+		items := make([]interface{}, 0, len(array.Items)+1)
+		items = append(items, array.Items[:index]...)
+		items = append(items, value)
+		array.Items = append(items, array.Items[index:]...)
+		array.Count = uint32(len(array.Items))
+		property.Value = array
+	}
+
+	return resolved.updateSizes()
+}
+
+// RemoveArrayItem removes the item at index from the array property at path.
+func RemoveArrayItem(archive *SaveArchive, path Path, index int) error {
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	property, err := arrayProperty(resolved)
+	if err != nil {
+		return err
+	}
+
+	switch array := property.Value.(type) {
+	case ArrayStructProperty:
+		if index < 0 || index >= len(array.Items) {
+			return fmt.Errorf("index %d out of range [0, %d)", index, len(array.Items))
+		}
+		items := make([]StructProperty, 0, len(array.Items)-1)
+		items = append(items, array.Items[:index]...)
+		array.Items = append(items, array.Items[index+1:]...)
+		array.Count = uint32(len(array.Items))
+		property.Value = array
+
+	case ArrayProperty:
+		if index < 0 || index >= len(array.Items) {
+			return fmt.Errorf("index %d out of range [0, %d)", index, len(array.Items))
+		}
+		items := make([]interface{}, 0, len(array.Items)-1)
+		items = append(items, array.Items[:index]...)
+		array.Items = append(items, array.Items[index+1:]...)
+		array.Count = uint32(len(array.Items))
+		property.Value = array
+	}
+
+	return resolved.updateSizes()
+}
+
+// SetMapEntry sets the value stored under key in the map property at path,
+// adding a new entry if the key is not present yet.
+func SetMapEntry(archive *SaveArchive, path Path, key interface{}, value interface{}) error {
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	property, err := resolved.property()
+	if err != nil {
+		return err
+	}
+
+	mapProperty, ok := property.Value.(MapProperty)
+	if !ok {
+		return fmt.Errorf("%s is not a map: %s", property.Name, property.Type)
+	}
+	saveData := resolved.saveData(len(resolved.chain) - 1)
+
+	err = checkValue(mapProperty.KeyType, key, saveData, true)
+	if err != nil {
+		return fmt.Errorf("map key: %w", err)
+	}
+	err = checkValue(mapProperty.ValueType, value, saveData, true)
+	if err != nil {
+		return fmt.Errorf("map value: %w", err)
+	}
+
+	values := make([]MapPropertyValue, len(mapProperty.Values), len(mapProperty.Values)+1)
+	copy(values, mapProperty.Values)
+
+	found := false
+	for i := range values {
+		if reflect.DeepEqual(values[i].Key, key) {
+			values[i].Value = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		values = append(values, MapPropertyValue{Key: key, Value: value})
+	}
+
+	mapProperty.Values = values
+	property.Value = mapProperty
+
+	return resolved.updateSizes()
+}
+
+// DeleteProperty removes the property at path from the object, component,
+// struct or variables that contains it.
+func DeleteProperty(archive *SaveArchive, path Path) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path does not address a property")
+	}
+
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	_, err = resolved.property()
+	if err != nil {
+		return err
+	}
+
+	err = resolved.edges[len(resolved.edges)-1].remove()
+	if err != nil {
+		return err
+	}
+
+	resolved.chain = resolved.chain[:len(resolved.chain)-1]
+	return resolved.updateSizes()
+}
+
+// RemoveMapEntry removes the entry stored under key from the map property at
+// path.
+func RemoveMapEntry(archive *SaveArchive, path Path, key interface{}) error {
+	resolved, err := resolvePath(archive, path)
+	if err != nil {
+		return err
+	}
+
+	property, err := resolved.property()
+	if err != nil {
+		return err
+	}
+
+	mapProperty, ok := property.Value.(MapProperty)
+	if !ok {
+		return fmt.Errorf("%s is not a map: %s", property.Name, property.Type)
+	}
+
+	for i := range mapProperty.Values {
+		if !reflect.DeepEqual(mapProperty.Values[i].Key, key) {
+			continue
+		}
+
+		values := make([]MapPropertyValue, 0, len(mapProperty.Values)-1)
+		values = append(values, mapProperty.Values[:i]...)
+		mapProperty.Values = append(values, mapProperty.Values[i+1:]...)
+		property.Value = mapProperty
+
+		return resolved.updateSizes()
+	}
+
+	return fmt.Errorf("key %v not found in %s", key, property.Name)
+}
+
+// propertyList returns the properties held by an object, component, struct or
+// variables node, and a function that stores changes made to them.
+func propertyList(node Node) (*[]Property, func(), bool) {
+	switch n := node.(type) {
+	case *UObject:
+		return &n.Properties, func() {}, true
+
+	case *Component:
+		return &n.Properties, func() {}, true
+
+	case *StructProperty:
+		properties, ok := n.Value.([]Property)
+		return &properties, func() { n.Value = properties }, ok
+
+	case *Property:
+		switch v := n.Value.(type) {
+		case StructProperty:
+			properties, ok := v.Value.([]Property)
+			return &properties, func() {
+				v.Value = properties
+				n.Value = v
+			}, ok
+
+		case Variables:
+			return &v.Properties, func() { n.Value = v }, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// resolveContainer resolves the node that holds the property at path. Object
+// properties are addressed as Objects/<id>/Properties/<name>.
+func resolveContainer(archive *SaveArchive, path Path) (*resolvedPath, error) {
+	if len(path) >= 2 && path[len(path)-2] == "Properties" {
+		resolved, err := resolvePath(archive, path[:len(path)-2])
+		if err == nil {
+			if _, ok := resolved.chain[len(resolved.chain)-1].(*UObject); ok {
+				return resolved, nil
+			}
+		}
+	}
+
+	return resolvePath(archive, path[:len(path)-1])
+}
+
+// AddProperty adds property to the object, component, struct or variables
+// that contains path. The last path segment must be the key of the property.
+func AddProperty(archive *SaveArchive, path Path, property Property) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path does not address a property")
+	}
+
+	key := propertyKey(&property)
+	if key != path[len(path)-1] {
+		return fmt.Errorf("property %s does not match path %s", key, path)
+	}
+
+	resolved, err := resolveContainer(archive, path)
+	if err != nil {
+		return err
+	}
+	last := len(resolved.chain) - 1
+
+	properties, store, ok := propertyList(resolved.chain[last])
+	if !ok {
+		return fmt.Errorf("%s cannot hold properties", path[:len(path)-1])
+	}
+
+	for i := range *properties {
+		if propertyKey(&(*properties)[i]) == key {
+			return fmt.Errorf("property %s already exists", key)
+		}
+	}
+
+	err = checkProperty(&property, resolved.saveData(last))
+	if err != nil {
+		return err
+	}
+
+	*properties = append(*properties, property)
+	store()
+
+	return resolved.updateSizes()
+}
@@ -0,0 +1,164 @@
+package remnant
+
+import (
+	"reflect"
+	"revision-go/ue"
+	"testing"
+)
+
+func testWorldArchive() *SaveArchive {
+	classPath := ue.FTopLevelAssetPath{Path: REMNANT_SAVE_GAME, Name: "BP_RemnantSaveGame_C"}
+	transform := ue.FTransform{
+		Rotation: ue.FQuaternion{W: 1},
+		Position: ue.FVector{X: 100, Y: -200, Z: 300},
+		Scale:    ue.FVector{X: 1, Y: 1, Z: 1},
+	}
+
+	actorArchive := func(objectPath string) SaveData {
+		return SaveData{
+			Objects: []UObject{
+				{
+					ObjectPath: objectPath,
+					LoadedData: &UObjectLoadedData{Name: "Actor"},
+					Properties: []Property{
+						{Name: "Active", Type: "BoolProperty", Value: true},
+					},
+				},
+			},
+		}
+	}
+
+	return &SaveArchive{
+		Header: SaveHeader{SaveGameFileVersion: 9, BuildNumber: 1},
+		Data: SaveData{
+			PackageVersion:    &PackageVersion{UE4Version: 1, UE5Version: 2},
+			SaveGameClassPath: &classPath,
+			Objects: []UObject{
+				{
+					WasLoaded:  true,
+					ObjectPath: classPath.Path,
+					Properties: []Property{
+						{Name: "Persistence", Type: "StructProperty", Value: StructProperty{
+							Name: "PersistenceBlob",
+							Value: PersistenceContainer{
+								Version:   3,
+								Destroyed: []uint64{7},
+								Actors: map[uint64]Actor{
+									1: {Transform: &transform, Archive: actorArchive("/Game/Test/ZoneActor")},
+									// saved with a transform that happens to be zero
+									2: {Transform: &ue.FTransform{}, Archive: actorArchive("/Game/Test/Checkpoint")},
+									3: {Archive: actorArchive("/Game/Test/Quest")},
+									4: {
+										Transform: &transform,
+										Archive:   actorArchive("/Game/Test/Spawned"),
+										DynamicData: &DynamicActor{
+											UniqueID:  4,
+											Transform: &transform,
+											ClassPath: ue.FTopLevelAssetPath{Path: "/Game/Test/Spawned", Name: "Spawned_C"},
+										},
+									},
+									5: {
+										DynamicData: &DynamicActor{
+											UniqueID:  5,
+											Transform: &transform,
+											ClassPath: ue.FTopLevelAssetPath{Path: "/Game/Test/Dropped", Name: "Dropped_C"},
+										},
+										DynamicOnly: true,
+									},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// encodeDecode encodes an archive and decodes the result.
+func encodeDecode(t *testing.T, archive *SaveArchive) SaveArchive {
+	t.Helper()
+
+	data, err := EncodeSave(archive)
+	if err != nil {
+		t.Fatalf("EncodeSave: %v", err)
+	}
+
+	decoded, err := DecodeSave(data)
+	if err != nil {
+		t.Fatalf("DecodeSave: %v", err)
+	}
+
+	return decoded
+}
+
+func TestEncodeDecodeWorldSave(t *testing.T) {
+	original := testWorldArchive()
+	archive := encodeDecode(t, original)
+
+	// sizes and names tables are only known once encoded, so the decoded save
+	// is compared with the result of encoding and decoding it again
+	again := encodeDecode(t, &archive)
+	if !reflect.DeepEqual(again, archive) {
+		t.Errorf("decoding the encoded save changed it")
+	}
+
+	property := archive.Data.Objects[0].Properties[0]
+	container, ok := property.Value.(StructProperty).Value.(PersistenceContainer)
+	if !ok {
+		t.Fatalf("decoded %T, want a persistence container", property.Value.(StructProperty).Value)
+	}
+
+	want := original.Data.Objects[0].Properties[0].Value.(StructProperty).Value.(PersistenceContainer)
+	if container.Version != want.Version || !reflect.DeepEqual(container.Destroyed, want.Destroyed) {
+		t.Errorf("decoded version %d destroyed %v, want %d %v", container.Version, container.Destroyed, want.Version, want.Destroyed)
+	}
+	if len(container.Actors) != len(want.Actors) {
+		t.Fatalf("decoded %d actors, want %d", len(container.Actors), len(want.Actors))
+	}
+	for id, wantActor := range want.Actors {
+		actor := container.Actors[id]
+		if !reflect.DeepEqual(actor.Transform, wantActor.Transform) {
+			t.Errorf("actor %d: transform %v, want %v", id, actor.Transform, wantActor.Transform)
+		}
+		if !reflect.DeepEqual(actor.DynamicData, wantActor.DynamicData) || actor.DynamicOnly != wantActor.DynamicOnly {
+			t.Errorf("actor %d: dynamic data %v %v, want %v %v", id, actor.DynamicData, actor.DynamicOnly, wantActor.DynamicData, wantActor.DynamicOnly)
+		}
+		if len(actor.Archive.Objects) != len(wantActor.Archive.Objects) {
+			t.Errorf("actor %d: %d objects, want %d", id, len(actor.Archive.Objects), len(wantActor.Archive.Objects))
+		}
+	}
+}
+
+func TestSetPropertyUpdatesSizes(t *testing.T) {
+	archive := encodeDecode(t, testArchive())
+
+	title := Path{"Objects", "0", "Properties", "Title"}
+	err := SetProperty(&archive, title, "A longer title for the test save")
+	if err != nil {
+		t.Fatalf("SetProperty: %v", err)
+	}
+
+	tags := Path{"Objects", "0", "Properties", "Tags"}
+	err = AddArrayItem(&archive, tags, 2, "Third")
+	if err != nil {
+		t.Fatalf("AddArrayItem: %v", err)
+	}
+
+	properties := archive.Data.Objects[0].Properties
+	// length, characters and terminating zero
+	if size := properties[2].Size; size != 4+32+1 {
+		t.Errorf("title size %d, want %d", size, 4+32+1)
+	}
+	if array := properties[5].Value.(ArrayProperty); array.Count != 3 {
+		t.Errorf("tags count %d, want 3", array.Count)
+	}
+
+	decoded := encodeDecode(t, &archive)
+	for i, property := range decoded.Data.Objects[0].Properties {
+		edited := properties[i]
+		if property.Size != edited.Size || !reflect.DeepEqual(property.Value, edited.Value) {
+			t.Errorf("property %s: decoded size %d value %#v, want %d %#v", property.Name, property.Size, property.Value, edited.Size, edited.Value)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package remnant
+
+import (
+	"fmt"
+	"math"
+	"revision-go/internal/tree"
+	"strconv"
+	"time"
+)
+
+// ticks of DateTime and Timespan are 100 nanoseconds, DateTime counts from
+// 0001-01-01
+const (
+	ticksPerSecond = 10000000
+	unixEpochTicks = 621355968000000000
+)
+
+func hasKeys(n *tree.Node, keys ...string) bool {
+	if n.Kind != tree.MappingNode || len(n.Keys) != len(keys) {
+		return false
+	}
+	for i, key := range keys {
+		if n.Keys[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+func parseUint32(n *tree.Node) uint32 {
+	value, _ := strconv.ParseUint(n.Value, 10, 32)
+	return uint32(value)
+}
+
+func parseFloat(n *tree.Node) float64 {
+	value, _ := strconv.ParseFloat(n.Value, 64)
+	return value
+}
+
+func formatDegrees(value float64) *tree.Node {
+	value = math.Round(value*1e4) / 1e4
+	if value == 0 {
+		value = 0
+	}
+	return tree.NewScalar(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// normalizeAxis maps an angle to (-180, 180].
+func normalizeAxis(angle float64) float64 {
+	angle = math.Mod(angle, 360)
+	if angle > 180 {
+		angle -= 360
+	} else if angle <= -180 {
+		angle += 360
+	}
+	return angle
+}
+
+// rotator converts a quaternion to pitch, yaw and roll in degrees, the same
+// as FQuat::Rotator.
+func rotator(x, y, z, w float64) (float64, float64, float64) {
+	const singularityThreshold = 0.4999995
+	const degrees = 180 / math.Pi
+
+	singularityTest := z*x - w*y
+	yaw := math.Atan2(2*(w*z+x*y), 1-2*(y*y+z*z)) * degrees
+
+	switch {
+	case singularityTest < -singularityThreshold:
+		return -90, yaw, normalizeAxis(-yaw - 2*math.Atan2(x, w)*degrees)
+	case singularityTest > singularityThreshold:
+		return 90, yaw, normalizeAxis(yaw - 2*math.Atan2(x, w)*degrees)
+	}
+
+	pitch := math.Asin(2*singularityTest) * degrees
+	roll := math.Atan2(-2*(w*x+y*z), 1-2*(x*x+y*y)) * degrees
+	return pitch, yaw, roll
+}
+
+func prettyStructValue(structName string, value *tree.Node) *tree.Node {
+	if value.Kind != tree.ScalarNode || value.Type() != "int" {
+		return value
+	}
+	ticks, err := strconv.ParseInt(value.Value, 10, 64)
+	if err != nil {
+		return value
+	}
+
+	switch structName {
+	case "DateTime":
+		unixTicks := ticks - unixEpochTicks
+		seconds := unixTicks / ticksPerSecond
+		nanoseconds := unixTicks % ticksPerSecond * 100
+		return tree.NewString(time.Unix(seconds, nanoseconds).UTC().Format(time.RFC3339Nano))
+
+	case "Timespan":
+		if ticks > math.MaxInt64/100 || ticks < math.MinInt64/100 {
+			return value
+		}
+		return tree.NewString(time.Duration(ticks * 100).String())
+	}
+
+	return value
+}
+
+// prettyNode rewrites the JSON of a save for reading, see PrettyJSON.
+func prettyNode(n *tree.Node) *tree.Node {
+	for i := range n.Items {
+		n.Items[i] = prettyNode(n.Items[i])
+	}
+
+	switch {
+	case hasKeys(n, "A", "B", "C", "D"):
+		a, b, c, d := parseUint32(n.Items[0]), parseUint32(n.Items[1]), parseUint32(n.Items[2]), parseUint32(n.Items[3])
+		return tree.NewString(fmt.Sprintf("%08X-%04X-%04X-%04X-%04X%08X", a, b>>16, b&0xFFFF, c>>16, c&0xFFFF, d))
+
+	case hasKeys(n, "EnumType", "EnumValue"):
+		return n.Items[1]
+
+	case hasKeys(n, "ObjectID", "ClassName"):
+		if n.Items[0].Value == "-1" {
+			return tree.NewScalar("null")
+		}
+		reference := tree.NewMapping()
+		reference.Append("Path", n.Items[1])
+		reference.Append("ObjectID", n.Items[0])
+		return reference
+
+	case hasKeys(n, "Rotation", "Position", "Scale") && hasKeys(n.Items[0], "X", "Y", "Z", "W"):
+		q := n.Items[0].Items
+		pitch, yaw, roll := rotator(parseFloat(q[0]), parseFloat(q[1]), parseFloat(q[2]), parseFloat(q[3]))
+		rotation := tree.NewMapping()
+		rotation.Append("Pitch", formatDegrees(pitch))
+		rotation.Append("Yaw", formatDegrees(yaw))
+		rotation.Append("Roll", formatDegrees(roll))
+		n.Items[0] = rotation
+
+	case hasKeys(n, "Name", "GUID", "Value", "Size"):
+		n.Items[2] = prettyStructValue(n.Items[0].Value, n.Items[2])
+	}
+
+	return n
+}
+
+// PrettyJSON rewrites the JSON of (part of) a save for reading: DateTime as
+// ISO 8601, Timespan as a duration, GUIDs as hex strings, enums as their
+// value, rotations as pitch, yaw and roll in degrees and object references as
+// their path and ObjectID. The result cannot be imported.
+func PrettyJSON(data []byte) ([]byte, error) {
+	n, err := tree.FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return prettyNode(n).JSON()
+}
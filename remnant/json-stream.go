@@ -0,0 +1,331 @@
+package remnant
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"revision-go/ue"
+	"strings"
+)
+
+// JSONEncoder writes saves as JSON without building the whole document in
+// memory: objects are encoded and written one at a time.
+type JSONEncoder struct {
+	w      *bufio.Writer
+	indent string
+	pretty bool
+}
+
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: bufio.NewWriter(w)}
+}
+
+// SetIndent makes Encode indent every level with indent, the same as
+// json.MarshalIndent with an empty prefix. EncodeLines ignores it.
+func (e *JSONEncoder) SetIndent(indent string) {
+	e.indent = indent
+}
+
+// SetPretty makes the encoder rewrite values for reading, see PrettyJSON.
+func (e *JSONEncoder) SetPretty(pretty bool) {
+	e.pretty = pretty
+}
+
+func (e *JSONEncoder) marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !e.pretty {
+		return data, err
+	}
+	return PrettyJSON(data)
+}
+
+func (e *JSONEncoder) newline(depth int) {
+	if e.indent != "" {
+		e.w.WriteByte('\n')
+		e.w.WriteString(strings.Repeat(e.indent, depth))
+	}
+}
+
+func (e *JSONEncoder) key(depth int, first bool, name string) {
+	if !first {
+		e.w.WriteByte(',')
+	}
+	e.newline(depth)
+	e.w.WriteString(`"` + name + `":`)
+	if e.indent != "" {
+		e.w.WriteByte(' ')
+	}
+}
+
+func (e *JSONEncoder) value(depth int, v interface{}) error {
+	data, err := e.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if e.indent != "" {
+		var buf bytes.Buffer
+		err = json.Indent(&buf, data, strings.Repeat(e.indent, depth), e.indent)
+		if err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *JSONEncoder) objects(depth int, objects []UObject) error {
+	if objects == nil {
+		_, err := e.w.WriteString("null")
+		return err
+	}
+	if len(objects) == 0 {
+		_, err := e.w.WriteString("[]")
+		return err
+	}
+
+	e.w.WriteByte('[')
+	for i := range objects {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.newline(depth + 1)
+		err := e.value(depth+1, &objects[i])
+		if err != nil {
+			return err
+		}
+	}
+	e.newline(depth)
+	_, err := e.w.WriteString("]")
+	return err
+}
+
+// Encode writes the same document as encoding NewJSONDocument(*archive)
+// with encoding/json, followed by a newline.
+func (e *JSONEncoder) Encode(archive *SaveArchive) error {
+	e.w.WriteByte('{')
+	e.key(1, true, "FormatVersion")
+	err := e.value(1, JSONFormatVersion)
+	if err != nil {
+		return err
+	}
+
+	e.key(1, false, "Archive")
+	e.w.WriteByte('{')
+	e.key(2, true, "Header")
+	err = e.value(2, &archive.Header)
+	if err != nil {
+		return err
+	}
+
+	// the fields of SaveData in order, with the objects written one by one
+	e.key(2, false, "Data")
+	e.w.WriteByte('{')
+	data := reflect.ValueOf(&archive.Data).Elem()
+	for i := 0; i < data.NumField(); i++ {
+		name := data.Type().Field(i).Name
+		e.key(3, i == 0, name)
+		if name == "Objects" {
+			err = e.objects(3, archive.Data.Objects)
+		} else {
+			err = e.value(3, data.Field(i).Interface())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	e.newline(2)
+	e.w.WriteByte('}')
+	e.newline(1)
+	e.w.WriteByte('}')
+	e.newline(0)
+	e.w.WriteString("}\n")
+
+	return e.w.Flush()
+}
+
+// JSONLine is a line written by EncodeLines.
+type JSONLine struct {
+	// Save, Object or Actor
+	Kind  string
+	Path  Path
+	Value interface{}
+}
+
+// jsonLineSave is the value of the Save line: everything but the objects.
+type jsonLineSave struct {
+	FormatVersion     int
+	Header            SaveHeader
+	PackageVersion    *PackageVersion
+	SaveGameClassPath *ue.FTopLevelAssetPath
+	NamesTable        []string
+	Version           uint32
+}
+
+// replaceItems returns a copy of items in which the items fn returns true for
+// are replaced, or items itself and false if there are none.
+func replaceItems[T any](items []T, fn func(item *T) (T, bool)) ([]T, bool) {
+	var result []T
+	for i := range items {
+		item, ok := fn(&items[i])
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = append([]T(nil), items...)
+		}
+		result[i] = item
+	}
+	return result, result != nil
+}
+
+// The functions below return a copy of a value without the actors of the
+// persistence containers in it, and whether there were any containers. Only
+// the values on the way to a container are copied, the rest is shared.
+
+func dataWithoutActors(data *SaveData) (SaveData, bool) {
+	objects, ok := replaceItems(data.Objects, objectWithoutActors)
+	if !ok {
+		return *data, false
+	}
+	result := *data
+	result.Objects = objects
+	return result, true
+}
+
+func objectWithoutActors(object *UObject) (UObject, bool) {
+	properties, propertiesOK := replaceItems(object.Properties, propertyWithoutActors)
+	components, componentsOK := replaceItems(object.Components, func(component *Component) (Component, bool) {
+		properties, ok := replaceItems(component.Properties, propertyWithoutActors)
+		result := *component
+		result.Properties = properties
+		return result, ok
+	})
+
+	result := *object
+	if propertiesOK {
+		result.Properties = properties
+	}
+	if componentsOK {
+		result.Components = components
+	}
+	return result, propertiesOK || componentsOK
+}
+
+func propertyWithoutActors(property *Property) (Property, bool) {
+	result := *property
+	switch v := property.Value.(type) {
+	case StructProperty:
+		value, ok := structWithoutActors(&v)
+		result.Value = value
+		return result, ok
+
+	case ArrayStructProperty:
+		items, ok := replaceItems(v.Items, structWithoutActors)
+		v.Items = items
+		result.Value = v
+		return result, ok
+
+	case Variables:
+		properties, ok := replaceItems(v.Properties, propertyWithoutActors)
+		v.Properties = properties
+		result.Value = v
+		return result, ok
+	}
+	return result, false
+}
+
+func structWithoutActors(structProperty *StructProperty) (StructProperty, bool) {
+	result := *structProperty
+	switch v := structProperty.Value.(type) {
+	case []Property:
+		properties, ok := replaceItems(v, propertyWithoutActors)
+		result.Value = properties
+		return result, ok
+
+	case PersistenceBlob:
+		archive, ok := dataWithoutActors(&v.Archive)
+		v.Archive = archive
+		result.Value = v
+		return result, ok
+
+	case PersistenceContainer:
+		v.Actors = map[uint64]Actor{}
+		result.Value = v
+		return result, true
+	}
+	return result, false
+}
+
+// withoutActors returns an object or actor with the actors of the persistence
+// containers below it left out, without changing node itself.
+func withoutActors(node Node) Node {
+	switch n := node.(type) {
+	case *UObject:
+		if object, ok := objectWithoutActors(n); ok {
+			return &object
+		}
+	case *Actor:
+		if archive, ok := dataWithoutActors(&n.Archive); ok {
+			actor := *n
+			actor.Archive = archive
+			return &actor
+		}
+	}
+	return node
+}
+
+func (e *JSONEncoder) line(kind string, path Path, value interface{}) error {
+	data, err := e.marshal(JSONLine{Kind: kind, Path: path, Value: value})
+	if err != nil {
+		return err
+	}
+
+	e.w.Write(data)
+	return e.w.WriteByte('\n')
+}
+
+// EncodeLines writes an archive as newline delimited JSON: a Save line with
+// the header and names table, then a line for every object and every actor of
+// a persistence container, in the order of Walk. Actors are left out of the
+// line of the object or actor that holds them.
+func (e *JSONEncoder) EncodeLines(archive *SaveArchive) error {
+	err := e.line("Save", Path{}, jsonLineSave{
+		FormatVersion:     JSONFormatVersion,
+		Header:            archive.Header,
+		PackageVersion:    archive.Data.PackageVersion,
+		SaveGameClassPath: archive.Data.SaveGameClassPath,
+		NamesTable:        archive.Data.NamesTable,
+		Version:           archive.Data.Version,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = Walk(archive, func(path Path, node Node) error {
+		var kind string
+		switch node.(type) {
+		case *UObject:
+			// objects inside blobs and actors are part of their lines
+			if len(path) != 2 {
+				return nil
+			}
+			kind = "Object"
+		case *Actor:
+			kind = "Actor"
+		default:
+			return nil
+		}
+
+		return e.line(kind, path, withoutActors(node))
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
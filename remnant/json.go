@@ -0,0 +1,319 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"revision-go/ue"
+)
+
+// JSONFormatVersion is increased whenever the JSON representation of a save
+// changes in a way that older documents can no longer be imported.
+const JSONFormatVersion = 1
+
+// JSONDocument is the top level object of the JSON dump, see docs/json-format.md.
+type JSONDocument struct {
+	FormatVersion int
+	Archive       SaveArchive
+}
+
+func NewJSONDocument(archive SaveArchive) JSONDocument {
+	return JSONDocument{
+		FormatVersion: JSONFormatVersion,
+		Archive:       archive,
+	}
+}
+
+// ReadJSONDocument decodes a JSON dump back into the parsed model.
+func ReadJSONDocument(r io.Reader) (SaveArchive, error) {
+	document := JSONDocument{}
+
+	err := json.NewDecoder(r).Decode(&document)
+	if err != nil {
+		return SaveArchive{}, err
+	}
+
+	if document.FormatVersion != JSONFormatVersion {
+		return SaveArchive{}, fmt.Errorf(
+			"unsupported JSON format version %d, expected %d", document.FormatVersion, JSONFormatVersion,
+		)
+	}
+
+	return document.Archive, nil
+}
+
+func isJSONObject(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+func hasJSONKey(data json.RawMessage, key string) bool {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return false
+	}
+
+	_, ok := fields[key]
+	return ok
+}
+
+func decodeJSON[T any](data json.RawMessage) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// decodePropertyValue is the JSON counterpart of getPropertyValue.
+func decodePropertyValue(varType string, data json.RawMessage, raw bool) (interface{}, error) {
+	switch varType {
+	case "IntProperty":
+		return decodeJSON[int32](data)
+
+	case "Int16Property":
+		return decodeJSON[int16](data)
+
+	case "Int64Property":
+		return decodeJSON[int64](data)
+
+	case "UInt64Property":
+		return decodeJSON[uint64](data)
+
+	case "FloatProperty", "FloatProeprty":
+		return decodeJSON[float32](data)
+
+	case "DoubleProperty":
+		return decodeJSON[float64](data)
+
+	case "UInt16Property":
+		return decodeJSON[uint16](data)
+
+	case "UInt32Property":
+		return decodeJSON[uint32](data)
+
+	case "SoftClassPath", "SoftObjectProperty", "StrProperty", "NameProperty":
+		return decodeJSON[string](data)
+
+	case "BoolProperty":
+		return decodeJSON[bool](data)
+
+	case "MapProperty":
+		return decodeJSON[MapProperty](data)
+
+	case "EnumProperty":
+		return decodeJSON[EnumProperty](data)
+
+	case "TextProperty":
+		return decodeJSON[TextProperty](data)
+
+	case "ArrayProperty":
+		if hasJSONKey(data, "GUID") {
+			return decodeJSON[ArrayStructProperty](data)
+		}
+		return decodeJSON[ArrayProperty](data)
+
+	case "StructProperty":
+		if raw {
+			return decodeJSON[StructReference](data)
+		}
+		return decodeJSON[StructProperty](data)
+
+	case "ObjectProperty":
+		return decodeJSON[ObjectProperty](data)
+
+	case "ByteProperty":
+		if !raw && isJSONObject(data) {
+			return decodeJSON[EnumProperty](data)
+		}
+		return decodeJSON[uint8](data)
+
+	case "None":
+		return nil, nil
+	}
+
+	if variablesComponents[varType] {
+		return decodeJSON[Variables](data)
+	}
+
+	return nil, fmt.Errorf("property type is not supported yet: %s", varType)
+}
+
+// decodeStructValue is the JSON counterpart of readStructPropertyData.
+func decodeStructValue(structName string, data json.RawMessage) (interface{}, error) {
+	switch structName {
+	case "SoftClassPath", "SoftObjectPath":
+		return decodeJSON[string](data)
+
+	case "Timespan", "DateTime":
+		return decodeJSON[int64](data)
+
+	case "Guid":
+		return decodeJSON[ue.FGuid](data)
+
+	case "Vector":
+		return decodeJSON[ue.FVector](data)
+
+	case "PersistenceBlob":
+		if hasJSONKey(data, "Archive") {
+			return decodeJSON[PersistenceBlob](data)
+		}
+		return decodeJSON[PersistenceContainer](data)
+
+	default:
+		return decodeJSON[[]Property](data)
+	}
+}
+
+func (p *Property) UnmarshalJSON(data []byte) error {
+	var property struct {
+		Name  string
+		Index uint32
+		Type  string
+		Size  uint32
+		Value json.RawMessage
+	}
+	err := json.Unmarshal(data, &property)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if property.Name == "FowVisitedCoordinates" {
+		value, err = decodeJSON[[]byte](property.Value)
+	} else {
+		value, err = decodePropertyValue(property.Type, property.Value, false)
+	}
+	if err != nil {
+		return fmt.Errorf("property %s (%s): %w", property.Name, property.Type, err)
+	}
+
+	*p = Property{
+		Name:  property.Name,
+		Index: property.Index,
+		Type:  property.Type,
+		Size:  property.Size,
+		Value: value,
+	}
+	return nil
+}
+
+func (p *StructProperty) UnmarshalJSON(data []byte) error {
+	var structProperty struct {
+		Name  string
+		GUID  ue.FGuid
+		Value json.RawMessage
+		Size  uint32
+	}
+	err := json.Unmarshal(data, &structProperty)
+	if err != nil {
+		return err
+	}
+
+	value, err := decodeStructValue(structProperty.Name, structProperty.Value)
+	if err != nil {
+		return fmt.Errorf("struct %s: %w", structProperty.Name, err)
+	}
+
+	*p = StructProperty{
+		Name:  structProperty.Name,
+		GUID:  structProperty.GUID,
+		Value: value,
+		Size:  structProperty.Size,
+	}
+	return nil
+}
+
+func (p *ArrayProperty) UnmarshalJSON(data []byte) error {
+	var arrayProperty struct {
+		Count       uint32
+		Items       []json.RawMessage
+		ElementType string
+	}
+	err := json.Unmarshal(data, &arrayProperty)
+	if err != nil {
+		return err
+	}
+
+	items := make([]interface{}, len(arrayProperty.Items))
+	for i, item := range arrayProperty.Items {
+		items[i], err = decodePropertyValue(arrayProperty.ElementType, item, true)
+		if err != nil {
+			return fmt.Errorf("array item %d: %w", i, err)
+		}
+	}
+
+	*p = ArrayProperty{
+		Count:       arrayProperty.Count,
+		Items:       items,
+		ElementType: arrayProperty.ElementType,
+	}
+	return nil
+}
+
+func (p *MapProperty) UnmarshalJSON(data []byte) error {
+	var mapProperty struct {
+		KeyType   string
+		ValueType string
+		Values    []struct {
+			Key   json.RawMessage
+			Value json.RawMessage
+		}
+	}
+	err := json.Unmarshal(data, &mapProperty)
+	if err != nil {
+		return err
+	}
+
+	values := make([]MapPropertyValue, len(mapProperty.Values))
+	for i, entry := range mapProperty.Values {
+		values[i].Key, err = decodePropertyValue(mapProperty.KeyType, entry.Key, true)
+		if err != nil {
+			return fmt.Errorf("map key %d: %w", i, err)
+		}
+		values[i].Value, err = decodePropertyValue(mapProperty.ValueType, entry.Value, true)
+		if err != nil {
+			return fmt.Errorf("map value %d: %w", i, err)
+		}
+	}
+
+	*p = MapProperty{
+		KeyType:   mapProperty.KeyType,
+		ValueType: mapProperty.ValueType,
+		Values:    values,
+	}
+	return nil
+}
+
+func (p *TextProperty) UnmarshalJSON(data []byte) error {
+	var textProperty struct {
+		Flags       uint32
+		HistoryType uint8
+		Data        json.RawMessage
+	}
+	err := json.Unmarshal(data, &textProperty)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	isNull := len(textProperty.Data) == 0 || string(bytes.TrimSpace(textProperty.Data)) == "null"
+	switch {
+	case textProperty.HistoryType == 255 && isNull:
+		value = nil
+	case textProperty.HistoryType == 255:
+		value, err = decodeJSON[TextData](textProperty.Data)
+	default:
+		value, err = decodeJSON[TextPropertyData](textProperty.Data)
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = TextProperty{
+		Flags:       textProperty.Flags,
+		HistoryType: textProperty.HistoryType,
+		Data:        value,
+	}
+	return nil
+}
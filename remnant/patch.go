@@ -0,0 +1,380 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// PatchOperation is a single step of a patch file, see docs/patch-format.md.
+type PatchOperation struct {
+	Op    string
+	Path  Path
+	Value json.RawMessage
+}
+
+// ReadPatch decodes a JSON array of patch operations.
+func ReadPatch(r io.Reader) ([]PatchOperation, error) {
+	operations := []PatchOperation{}
+
+	err := json.NewDecoder(r).Decode(&operations)
+	if err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
+// patchTarget is what a patch path addresses: a node, or an item of a scalar
+// array, which is replaced through its array.
+type patchTarget struct {
+	node  Node
+	array *Property
+	index int
+}
+
+func (t *patchTarget) value() interface{} {
+	if t.array != nil {
+		return t.array.Value.(ArrayProperty).Items[t.index]
+	}
+
+	switch n := t.node.(type) {
+	case *Property:
+		return n.Value
+	case *StructProperty:
+		return *n
+	case *MapPropertyValue:
+		return *n
+	}
+
+	return t.node
+}
+
+func parseIndex(segment string, length int) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || index >= length {
+		return 0, fmt.Errorf("index %s out of range [0, %d)", segment, length)
+	}
+	return index, nil
+}
+
+func findPatchTarget(archive *SaveArchive, path Path) (*patchTarget, error) {
+	node, err := Lookup(archive, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := node.(*interface{}); !ok {
+		return &patchTarget{node: node}, nil
+	}
+
+	array := parentProperty(archive, path)
+	index, err := parseIndex(path[len(path)-1], len(array.Value.(ArrayProperty).Items))
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchTarget{array: array, index: index}, nil
+}
+
+// parentProperty returns the array or map property that contains the item at
+// path, or nil if the parent of path is something else.
+func parentProperty(archive *SaveArchive, path Path) *Property {
+	if len(path) == 0 {
+		return nil
+	}
+
+	parent, err := Lookup(archive, path[:len(path)-1])
+	if err != nil {
+		return nil
+	}
+
+	property, ok := parent.(*Property)
+	if !ok {
+		return nil
+	}
+
+	switch property.Value.(type) {
+	case ArrayProperty, ArrayStructProperty, MapProperty:
+		return property
+	}
+
+	return nil
+}
+
+func decodeMapEntry(mapProperty MapProperty, data json.RawMessage) (interface{}, interface{}, error) {
+	var entry struct {
+		Key   json.RawMessage
+		Value json.RawMessage
+	}
+	err := json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := decodePropertyValue(mapProperty.KeyType, entry.Key, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("map key: %w", err)
+	}
+
+	value, err := decodePropertyValue(mapProperty.ValueType, entry.Value, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("map value: %w", err)
+	}
+
+	return key, value, nil
+}
+
+func decodeArrayItem(property *Property, data json.RawMessage) (interface{}, error) {
+	switch array := property.Value.(type) {
+	case ArrayStructProperty:
+		return decodeJSON[StructProperty](data)
+	case ArrayProperty:
+		return decodePropertyValue(array.ElementType, data, true)
+	}
+	return nil, fmt.Errorf("%s is not an array: %s", property.Name, property.Type)
+}
+
+// exactNumber is a JSON number as an exact fraction, see decodeExact.
+type exactNumber string
+
+func exactNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(string(v)); ok {
+			return exactNumber(r.RatString())
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = exactNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = exactNumbers(v[key])
+		}
+	}
+	return value
+}
+
+// decodeExact decodes JSON for comparing it: numbers are kept exact instead of
+// being rounded to float64, so that large 64-bit integers differing in their
+// last digits are not equal, while 1, 1.0 and 1e0 are.
+func decodeExact(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	return exactNumbers(value), nil
+}
+
+func testOperation(archive *SaveArchive, operation *PatchOperation) error {
+	target, err := findPatchTarget(archive, operation.Path)
+	if err != nil {
+		return err
+	}
+
+	current, err := json.Marshal(target.value())
+	if err != nil {
+		return err
+	}
+
+	actual, err := decodeExact(current)
+	if err != nil {
+		return err
+	}
+	expected, err := decodeExact(operation.Value)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		return fmt.Errorf("test failed: %s is %s", operation.Path, current)
+	}
+
+	return nil
+}
+
+func setOperation(archive *SaveArchive, operation *PatchOperation) error {
+	path := operation.Path
+
+	target, err := findPatchTarget(archive, path)
+	if err != nil {
+		return err
+	}
+
+	if target.array != nil {
+		array := target.array.Value.(ArrayProperty)
+		item, err := decodePropertyValue(array.ElementType, operation.Value, true)
+		if err != nil {
+			return err
+		}
+
+		items := make([]interface{}, len(array.Items))
+		copy(items, array.Items)
+		items[target.index] = item
+		array.Items = items
+
+		return SetProperty(archive, path[:len(path)-1], array)
+	}
+
+	switch n := target.node.(type) {
+	case *Property:
+		var value interface{}
+		if n.Name == "FowVisitedCoordinates" {
+			value, err = decodeJSON[[]byte](operation.Value)
+		} else {
+			value, err = decodePropertyValue(n.Type, operation.Value, false)
+		}
+		if err != nil {
+			return err
+		}
+		return SetProperty(archive, path, value)
+
+	case *StructProperty:
+		item, err := decodeJSON[StructProperty](operation.Value)
+		if err != nil {
+			return err
+		}
+
+		parent := parentProperty(archive, path)
+		if parent == nil {
+			return fmt.Errorf("%s is not an array item", path)
+		}
+		array := parent.Value.(ArrayStructProperty)
+		index, err := parseIndex(path[len(path)-1], len(array.Items))
+		if err != nil {
+			return err
+		}
+		item.GUID = array.GUID
+		items := make([]StructProperty, len(array.Items))
+		copy(items, array.Items)
+		items[index] = item
+		array.Items = items
+
+		return SetProperty(archive, path[:len(path)-1], array)
+
+	case *MapPropertyValue:
+		parent := parentProperty(archive, path)
+		if parent == nil {
+			return fmt.Errorf("%s is not a map entry", path)
+		}
+		mapProperty := parent.Value.(MapProperty)
+		value, err := decodePropertyValue(mapProperty.ValueType, operation.Value, true)
+		if err != nil {
+			return err
+		}
+		return SetMapEntry(archive, path[:len(path)-1], n.Key, value)
+	}
+
+	return fmt.Errorf("%s cannot be set", path)
+}
+
+func addOperation(archive *SaveArchive, operation *PatchOperation) error {
+	path := operation.Path
+
+	parent := parentProperty(archive, path)
+	if parent == nil {
+		property, err := decodeJSON[Property](operation.Value)
+		if err != nil {
+			return err
+		}
+		return AddProperty(archive, path, property)
+	}
+	parentPath := path[:len(path)-1]
+
+	if mapProperty, ok := parent.Value.(MapProperty); ok {
+		key, value, err := decodeMapEntry(mapProperty, operation.Value)
+		if err != nil {
+			return err
+		}
+		return SetMapEntry(archive, parentPath, key, value)
+	}
+
+	item, err := decodeArrayItem(parent, operation.Value)
+	if err != nil {
+		return err
+	}
+
+	// "-" appends, as in JSON Patch
+	var index int
+	segment := path[len(path)-1]
+	if segment == "-" {
+		switch array := parent.Value.(type) {
+		case ArrayStructProperty:
+			index = len(array.Items)
+		case ArrayProperty:
+			index = len(array.Items)
+		}
+	} else {
+		index, err = strconv.Atoi(segment)
+		if err != nil {
+			return fmt.Errorf("invalid array index %s", segment)
+		}
+	}
+
+	return AddArrayItem(archive, parentPath, index, item)
+}
+
+func removeOperation(archive *SaveArchive, operation *PatchOperation) error {
+	path := operation.Path
+
+	target, err := findPatchTarget(archive, path)
+	if err != nil {
+		return err
+	}
+
+	if target.array != nil {
+		return RemoveArrayItem(archive, path[:len(path)-1], target.index)
+	}
+
+	switch n := target.node.(type) {
+	case *Property:
+		return DeleteProperty(archive, path)
+
+	case *StructProperty:
+		index, err := strconv.Atoi(path[len(path)-1])
+		if err != nil {
+			return err
+		}
+		return RemoveArrayItem(archive, path[:len(path)-1], index)
+
+	case *MapPropertyValue:
+		return RemoveMapEntry(archive, path[:len(path)-1], n.Key)
+	}
+
+	return fmt.Errorf("%s cannot be removed", path)
+}
+
+// ApplyPatch applies the operations in order. It stops at the first operation
+// that fails, including a failed test, leaving the archive partially patched.
+func ApplyPatch(archive *SaveArchive, operations []PatchOperation) error {
+	for i := range operations {
+		operation := &operations[i]
+
+		var err error
+		switch operation.Op {
+		case "test":
+			err = testOperation(archive, operation)
+		case "set":
+			err = setOperation(archive, operation)
+		case "add":
+			err = addOperation(archive, operation)
+		case "remove":
+			err = removeOperation(archive, operation)
+		default:
+			err = fmt.Errorf("unknown operation %q", operation.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return nil
+}
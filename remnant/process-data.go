@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"revision-go/memory"
 	"revision-go/ue"
+	"strconv"
 )
 
 type OffsetInfo struct {
@@ -22,6 +24,8 @@ type UObject struct {
 	LoadedData *UObjectLoadedData
 	Properties []Property
 	Components []Component
+	// bytes at the end of the object data that could not be parsed
+	UnreadData []byte
 }
 
 type UObjectLoadedData struct {
@@ -32,6 +36,8 @@ type UObjectLoadedData struct {
 type Component struct {
 	ComponentKey string
 	Properties   []Property
+	// bytes at the end of the component data that could not be parsed
+	UnreadData []byte
 }
 
 type ArrayStructProperty struct {
@@ -86,6 +92,16 @@ const (
 	VarTypeName  = 4
 )
 
+// components whose data is a list of variables rather than properties
+var variablesComponents = map[string]bool{
+	"GlobalVariables":  true,
+	"Variables":        true,
+	"Variable":         true,
+	"PersistenceKeys":  true,
+	"PersistanceKeys1": true,
+	"PersistenceKeys1": true,
+}
+
 var VarTypeNames = map[uint8]string{
 	VarTypeNone:  "None",
 	VarTypeBool:  "BoolProperty",
@@ -126,6 +142,7 @@ func readSaveData(r io.ReadSeeker, hasPackageVersion bool, hasTopLevelAssetPath
 			return result, fmt.Errorf("failed to read package version: %w", err)
 		}
 		result.PackageVersion = &packageVersion
+		traceField(r, "PackageVersion", packageVersion)
 	}
 	if hasTopLevelAssetPath {
 		saveGameClassPath, err := ue.ReadFTopLevelAssetPath(r)
@@ -133,6 +150,7 @@ func readSaveData(r io.ReadSeeker, hasPackageVersion bool, hasTopLevelAssetPath
 			return result, fmt.Errorf("failed to read top level asset path: %w", err)
 		}
 		result.SaveGameClassPath = &saveGameClassPath
+		traceField(r, "SaveGameClassPath", saveGameClassPath)
 	}
 
 	var offsets OffsetInfo
@@ -140,6 +158,7 @@ func readSaveData(r io.ReadSeeker, hasPackageVersion bool, hasTopLevelAssetPath
 	if err != nil {
 		return result, err
 	}
+	traceField(r, "Offsets", offsets)
 	objectsDataOffset, err := r.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return result, err
@@ -167,6 +186,7 @@ func ReadSaveArchive(r io.ReadSeeker) (SaveArchive, error) {
 	if err != nil {
 		return SaveArchive{}, err
 	}
+	traceField(r, "Header", header)
 
 	data, err := readSaveData(r, true, true)
 	if err != nil {
@@ -180,10 +200,13 @@ func ReadSaveArchive(r io.ReadSeeker) (SaveArchive, error) {
 }
 
 func readObject(r io.Reader, saveData *SaveData, objectID uint32) (UObject, error) {
+	defer tracePush(r, "Objects", strconv.FormatUint(uint64(objectID), 10))()
+
 	wasLoadedByte, err := memory.ReadInt[uint8](r)
 	if err != nil {
 		return UObject{}, err
 	}
+	traceField(r, "WasLoaded", wasLoadedByte)
 
 	wasLoaded := wasLoadedByte != 0
 
@@ -203,6 +226,7 @@ func readObject(r io.Reader, saveData *SaveData, objectID uint32) (UObject, erro
 			return UObject{}, err
 		}
 	}
+	traceField(r, "ObjectPath", objectPath)
 
 	var loadedData UObjectLoadedData
 	if !wasLoaded {
@@ -210,11 +234,13 @@ func readObject(r io.Reader, saveData *SaveData, objectID uint32) (UObject, erro
 		if err != nil {
 			return UObject{}, err
 		}
+		traceField(r, "Name", objectName)
 
 		outerID, err := memory.ReadInt[uint32](r)
 		if err != nil {
 			return UObject{}, err
 		}
+		traceField(r, "OuterID", outerID)
 
 		loadedData = UObjectLoadedData{
 			Name:    objectName,
@@ -227,7 +253,7 @@ func readObject(r io.Reader, saveData *SaveData, objectID uint32) (UObject, erro
 		WasLoaded:  wasLoaded,
 		ObjectPath: objectPath,
 		LoadedData: &loadedData,
-		Properties: make([]Property, 0),
+		Properties: nil,
 		Components: nil,
 	}, nil
 }
@@ -242,6 +268,13 @@ func readNamesTable(r io.ReadSeeker, namesTableOffset uint64) ([]string, error)
 	if err != nil {
 		return nil, err
 	}
+	traceField(r, "NamesCount", stringsNum)
+
+	// every name has at least its length
+	err = checkCount(r, int64(stringsNum), 4)
+	if err != nil {
+		return nil, err
+	}
 
 	names := make([]string, stringsNum)
 
@@ -251,6 +284,10 @@ func readNamesTable(r io.ReadSeeker, namesTableOffset uint64) ([]string, error)
 			return nil, err
 		}
 		names[i] = stringData
+
+		pop := tracePush(r, "NamesTable", strconv.Itoa(i))
+		traceField(r, "Name", stringData)
+		pop()
 	}
 
 	return names, nil
@@ -263,13 +300,18 @@ func readVariable(r io.ReadSeeker, saveData *SaveData) (*Property, error) {
 	}
 
 	if name == "None" {
+		traceField(r, "End", name)
 		return nil, nil
 	}
 
+	defer tracePush(r, name)()
+	traceField(r, "Name", name)
+
 	varTypeEnumValue, err := memory.ReadInt[uint8](r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read variable type: %w", err)
 	}
+	traceField(r, "Type", varTypeEnumValue)
 
 	varType := VarTypeNames[varTypeEnumValue]
 
@@ -298,7 +340,7 @@ func readVariable(r io.ReadSeeker, saveData *SaveData) (*Property, error) {
 			return nil, fmt.Errorf("failed to read variable value: %w", err)
 		}
 
-		varValue = float32(value)
+		varValue = math.Float32frombits(value)
 
 	case VarTypeName:
 		value, err := readName(r, saveData)
@@ -311,6 +353,7 @@ func readVariable(r io.ReadSeeker, saveData *SaveData) (*Property, error) {
 	default:
 		return nil, fmt.Errorf("unknown variable type: %d", varTypeEnumValue)
 	}
+	traceField(r, "Value", varValue)
 
 	return &Property{
 		Name:  name,
@@ -324,16 +367,24 @@ func readVariables(r io.ReadSeeker, saveData *SaveData) (Variables, error) {
 	if err != nil {
 		return Variables{}, fmt.Errorf("failed to read variable name index: %w", err)
 	}
+	traceField(r, "Name", name)
 
-	_, err = memory.ReadInt[uint64](r)
+	empty, err := memory.ReadInt[uint64](r)
 	if err != nil {
 		return Variables{}, fmt.Errorf("failed to read empty value: %w", err)
 	}
+	traceField(r, "Empty", empty)
 
 	arrayLength, err := memory.ReadInt[uint32](r)
 	if err != nil {
 		return Variables{}, fmt.Errorf("failed to read array length: %w", err)
 	}
+	traceField(r, "Count", arrayLength)
+
+	err = checkCount(r, int64(arrayLength), 1)
+	if err != nil {
+		return Variables{}, err
+	}
 
 	properties := make([]Property, 0, arrayLength)
 
@@ -356,6 +407,13 @@ func readComponents(r io.ReadSeeker, saveData *SaveData) ([]Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	traceField(r, "ComponentsCount", componentCount)
+
+	// every component has at least the length of its key and data
+	err = checkCount(r, int64(componentCount), 8)
+	if err != nil {
+		return nil, err
+	}
 
 	components := make([]Component, componentCount)
 
@@ -364,11 +422,14 @@ func readComponents(r io.ReadSeeker, saveData *SaveData) ([]Component, error) {
 		if err != nil {
 			return nil, err
 		}
+		pop := tracePush(r, "Components", componentKey)
+		traceField(r, "ComponentKey", componentKey)
 
 		objectLength, err := memory.ReadInt[uint32](r)
 		if err != nil {
 			return nil, err
 		}
+		traceField(r, "Length", objectLength)
 
 		startPos, err := r.Seek(0, io.SeekCurrent)
 		if err != nil {
@@ -376,68 +437,19 @@ func readComponents(r io.ReadSeeker, saveData *SaveData) ([]Component, error) {
 		}
 
 		properties := []Property{}
-		switch componentKey {
-		case "GlobalVariables":
-			variables, err := readVariables(r, saveData)
-			if err != nil {
-				return nil, err
-			}
-			properties = append(properties, Property{
-				Name:  componentKey,
-				Type:  componentKey,
-				Value: variables,
-			})
-		case "Variables":
-			variables, err := readVariables(r, saveData)
-			if err != nil {
-				return nil, err
-			}
-			properties = append(properties, Property{
-				Name:  componentKey,
-				Type:  componentKey,
-				Value: variables,
-			})
-		case "Variable":
+		if variablesComponents[componentKey] {
+			popVariables := tracePush(r, componentKey)
 			variables, err := readVariables(r, saveData)
 			if err != nil {
 				return nil, err
 			}
+			popVariables()
 			properties = append(properties, Property{
 				Name:  componentKey,
 				Type:  componentKey,
 				Value: variables,
 			})
-		case "PersistenceKeys":
-			variables, err := readVariables(r, saveData)
-			if err != nil {
-				return nil, err
-			}
-			properties = append(properties, Property{
-				Name:  componentKey,
-				Type:  componentKey,
-				Value: variables,
-			})
-		case "PersistanceKeys1":
-			variables, err := readVariables(r, saveData)
-			if err != nil {
-				return nil, err
-			}
-			properties = append(properties, Property{
-				Name:  componentKey,
-				Type:  componentKey,
-				Value: variables,
-			})
-		case "PersistenceKeys1":
-			variables, err := readVariables(r, saveData)
-			if err != nil {
-				return nil, err
-			}
-			properties = append(properties, Property{
-				Name:  componentKey,
-				Type:  componentKey,
-				Value: variables,
-			})
-		default:
+		} else {
 			properties, err = readProperties(r, saveData)
 			if err != nil {
 				return nil, err
@@ -449,21 +461,25 @@ func readComponents(r io.ReadSeeker, saveData *SaveData) ([]Component, error) {
 			return nil, err
 		}
 
+		var unreadData []byte
 		if currentPos-startPos != int64(objectLength) {
-			bytes := make([]byte, startPos+int64(objectLength)-currentPos)
-			_, err := r.Read(bytes)
+			bytes, err := memory.ReadBytes(r, startPos+int64(objectLength)-currentPos)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("component %s: %w", componentKey, err)
 			}
 			log.Printf(
 				"Did not read all component data. %d/%d bytes read at %d for %s (%v)\n",
 				currentPos-startPos, objectLength, startPos, componentKey, bytes,
 			)
+			unreadData = bytes
+			traceUnread(r, bytes)
 		}
+		pop()
 
 		components[i] = Component{
 			ComponentKey: componentKey,
 			Properties:   properties,
+			UnreadData:   unreadData,
 		}
 	}
 
@@ -480,6 +496,12 @@ func readObjects(r io.ReadSeeker, objectsTableOffset uint64, objectsDataOffset i
 	if err != nil {
 		return fmt.Errorf("failed to read numUniqueClasses: %w", err)
 	}
+	traceField(r, "ObjectsCount", numUniqueObjects)
+
+	err = checkCount(r, int64(numUniqueObjects), 1)
+	if err != nil {
+		return err
+	}
 
 	saveData.Objects = make([]UObject, numUniqueObjects)
 	for i := 0; i < int(numUniqueObjects); i++ {
@@ -499,6 +521,8 @@ func readObjects(r io.ReadSeeker, objectsTableOffset uint64, objectsDataOffset i
 		if err != nil {
 			return fmt.Errorf("failed to read object id: %w", err)
 		}
+		pop := tracePush(r, "Objects", strconv.FormatUint(uint64(objectID), 10))
+		traceField(r, "ObjectID", objectID)
 		object := saveData.Objects[objectID]
 
 		err = readObjectData(r, &object, saveData)
@@ -511,6 +535,7 @@ func readObjects(r io.ReadSeeker, objectsTableOffset uint64, objectsDataOffset i
 		if err != nil {
 			return fmt.Errorf("failed to read isActor: %w", err)
 		}
+		traceField(r, "IsActor", isActor)
 		if isActor != 0 {
 			object.Components, err = readComponents(r, saveData)
 			if err != nil {
@@ -518,6 +543,7 @@ func readObjects(r io.ReadSeeker, objectsTableOffset uint64, objectsDataOffset i
 			}
 		}
 		saveData.Objects[objectID] = object
+		pop()
 	}
 
 	return nil
@@ -528,6 +554,7 @@ func readObjectData(r io.ReadSeeker, object *UObject, saveData *SaveData) error
 	if err != nil {
 		return err
 	}
+	traceField(r, "Length", length)
 
 	startPos, err := r.Seek(0, io.SeekCurrent)
 	if err != nil {
@@ -535,10 +562,12 @@ func readObjectData(r io.ReadSeeker, object *UObject, saveData *SaveData) error
 	}
 
 	if length > 0 {
+		pop := tracePush(r, "Properties")
 		properties, err := readProperties(r, saveData)
 		if err != nil {
 			return err
 		}
+		pop()
 
 		currentPos, err := r.Seek(0, io.SeekCurrent)
 		if err != nil {
@@ -546,15 +575,16 @@ func readObjectData(r io.ReadSeeker, object *UObject, saveData *SaveData) error
 		}
 
 		if currentPos-startPos != int64(length) {
-			bytes := make([]byte, startPos+int64(length)-currentPos)
-			_, err = r.Read(bytes)
+			bytes, err := memory.ReadBytes(r, startPos+int64(length)-currentPos)
 			if err != nil {
-				return err
+				return fmt.Errorf("object %s: %w", object.ObjectPath, err)
 			}
 			log.Printf(
 				"Did not read all object data. %d/%d bytes read at %d for %s (%v)\n",
 				currentPos-startPos, length, startPos, object.ObjectPath, bytes,
 			)
+			object.UnreadData = bytes
+			traceUnread(r, bytes)
 		}
 
 		object.Properties = properties
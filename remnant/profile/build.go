@@ -0,0 +1,106 @@
+package profile
+
+import (
+	"revision-go/remnant"
+	"revision-go/ue"
+	"sort"
+	"strings"
+)
+
+type Archetype struct {
+	BlueprintPath string
+	Level         int32
+}
+
+type Trait struct {
+	BlueprintPath string
+	// trait points allocated to the trait
+	Level int32
+}
+
+type Skill struct {
+	BlueprintPath string
+	EquipmentSlot int32
+}
+
+// Build summarizes the archetypes, traits and skills of a character. Traits,
+// archetypes and skills are inventory items, told apart by blueprint path.
+type Build struct {
+	// empty if the character has no archetype in that slot
+	PrimaryArchetype   string
+	SecondaryArchetype string
+	Archetypes         []Archetype
+	// total of the trait levels
+	TraitPoints    int32
+	Traits         []Trait
+	EquippedSkills []Skill
+}
+
+func isArchetype(blueprintPath string) bool {
+	return strings.Contains(blueprintPath, "/Archetypes/") && !isSkill(blueprintPath) &&
+		strings.HasPrefix(ue.AssetName(blueprintPath), "Archetype_")
+}
+
+func isTrait(blueprintPath string) bool {
+	return strings.Contains(blueprintPath, "/Traits/")
+}
+
+func isSkill(blueprintPath string) bool {
+	return strings.Contains(blueprintPath, "/Skills/")
+}
+
+func readBuild(properties []remnant.Property, items []Item) Build {
+	build := Build{
+		Archetypes:     []Archetype{},
+		Traits:         []Trait{},
+		EquippedSkills: []Skill{},
+	}
+
+	build.PrimaryArchetype, _ = remnant.StringValue(properties, "Archetype")
+	build.SecondaryArchetype, _ = remnant.StringValue(properties, "SecondaryArchetype")
+
+	equippedArchetypes := []Item{}
+	for _, item := range items {
+		switch {
+		case isArchetype(item.BlueprintPath):
+			build.Archetypes = append(build.Archetypes, Archetype{
+				BlueprintPath: item.BlueprintPath,
+				Level:         item.Level,
+			})
+			if item.Equipped {
+				equippedArchetypes = append(equippedArchetypes, item)
+			}
+
+		case isTrait(item.BlueprintPath):
+			build.Traits = append(build.Traits, Trait{
+				BlueprintPath: item.BlueprintPath,
+				Level:         item.Level,
+			})
+			build.TraitPoints += item.Level
+
+		case isSkill(item.BlueprintPath) && item.Equipped:
+			build.EquippedSkills = append(build.EquippedSkills, Skill{
+				BlueprintPath: item.BlueprintPath,
+				EquipmentSlot: item.EquipmentSlot,
+			})
+		}
+	}
+
+	// without the character properties, the equipped archetypes in slot
+	// order are the primary and secondary ones
+	sort.SliceStable(equippedArchetypes, func(i, j int) bool {
+		return equippedArchetypes[i].EquipmentSlot < equippedArchetypes[j].EquipmentSlot
+	})
+	if build.PrimaryArchetype == "" && len(equippedArchetypes) > 0 {
+		build.PrimaryArchetype = equippedArchetypes[0].BlueprintPath
+	}
+	if build.SecondaryArchetype == "" && len(equippedArchetypes) > 1 {
+		build.SecondaryArchetype = equippedArchetypes[1].BlueprintPath
+	}
+
+	sort.SliceStable(build.EquippedSkills, func(i, j int) bool {
+		return build.EquippedSkills[i].EquipmentSlot < build.EquippedSkills[j].EquipmentSlot
+	})
+
+	return build
+}
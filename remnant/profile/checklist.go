@@ -0,0 +1,131 @@
+package profile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CatalogEntry is an item a player can collect, as listed in a user supplied
+// catalog file.
+type CatalogEntry struct {
+	BlueprintPath string
+	Name          string
+	Category      string
+}
+
+type CategoryStatus struct {
+	Category string
+	Owned    []CatalogEntry
+	Missing  []CatalogEntry
+	// percentage of owned entries, 0 to 100
+	Completion float64
+}
+
+// ReadCatalogJSON reads a JSON array of catalog entries.
+func ReadCatalogJSON(r io.Reader) ([]CatalogEntry, error) {
+	catalog := []CatalogEntry{}
+
+	err := json.NewDecoder(r).Decode(&catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// ReadCatalogCSV reads a catalog with a header row naming the BlueprintPath,
+// Name and Category columns, in any order and case.
+func ReadCatalogCSV(r io.Reader) ([]CatalogEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	pathColumn, ok := columns["blueprintpath"]
+	if !ok {
+		return nil, fmt.Errorf("catalog has no BlueprintPath column")
+	}
+	nameColumn, hasName := columns["name"]
+	categoryColumn, hasCategory := columns["category"]
+
+	catalog := []CatalogEntry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := CatalogEntry{BlueprintPath: record[pathColumn]}
+		if hasName {
+			entry.Name = record[nameColumn]
+		}
+		if hasCategory {
+			entry.Category = record[categoryColumn]
+		}
+		catalog = append(catalog, entry)
+	}
+
+	return catalog, nil
+}
+
+// assetPath drops the object name from a blueprint path, so that catalogs may
+// list either /Game/.../Ring_X or /Game/.../Ring_X.Ring_X_C.
+func assetPath(blueprintPath string) string {
+	if i := strings.LastIndex(blueprintPath, "."); i > strings.LastIndex(blueprintPath, "/") {
+		return blueprintPath[:i]
+	}
+	return blueprintPath
+}
+
+// Checklist compares the catalog to the items owned by the given characters.
+// Categories are returned in alphabetical order.
+func Checklist(catalog []CatalogEntry, characters []Character) []CategoryStatus {
+	owned := map[string]bool{}
+	for _, character := range characters {
+		for _, item := range character.Items {
+			owned[assetPath(item.BlueprintPath)] = true
+		}
+	}
+
+	statuses := map[string]*CategoryStatus{}
+	for _, entry := range catalog {
+		status, ok := statuses[entry.Category]
+		if !ok {
+			status = &CategoryStatus{
+				Category: entry.Category,
+				Owned:    []CatalogEntry{},
+				Missing:  []CatalogEntry{},
+			}
+			statuses[entry.Category] = status
+		}
+
+		if owned[assetPath(entry.BlueprintPath)] {
+			status.Owned = append(status.Owned, entry)
+		} else {
+			status.Missing = append(status.Missing, entry)
+		}
+	}
+
+	result := make([]CategoryStatus, 0, len(statuses))
+	for _, status := range statuses {
+		status.Completion = 100 * float64(len(status.Owned)) / float64(len(status.Owned)+len(status.Missing))
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Category < result[j].Category })
+
+	return result
+}
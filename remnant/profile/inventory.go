@@ -0,0 +1,83 @@
+package profile
+
+import (
+	"fmt"
+	"revision-go/remnant"
+)
+
+type Item struct {
+	ID            int32
+	BlueprintPath string
+	Quantity      int32
+	// level of upgradable items, 0 for others
+	Level    int32
+	New      bool
+	Favorite bool
+	Equipped bool
+	// index of the equipment slot, -1 if the item is not equipped
+	EquipmentSlot int32
+}
+
+// findCharacterObject returns the object of a character archive that carries
+// the inventory component.
+func findCharacterObject(archive *remnant.SaveData) *remnant.UObject {
+	for i := range archive.Objects {
+		if remnant.FindComponent(&archive.Objects[i], "Inventory") != nil {
+			return &archive.Objects[i]
+		}
+	}
+	return nil
+}
+
+func readInventory(archive *remnant.SaveData, object *remnant.UObject) ([]Item, error) {
+	inventory := remnant.FindComponent(object, "Inventory")
+
+	itemsProperty := remnant.FindProperty(inventory.Properties, "Items")
+	if itemsProperty == nil {
+		return []Item{}, nil
+	}
+
+	itemStructs, ok := itemsProperty.Value.(remnant.ArrayStructProperty)
+	if !ok {
+		return nil, fmt.Errorf("unexpected inventory Items value %T", itemsProperty.Value)
+	}
+
+	items := make([]Item, 0, len(itemStructs.Items))
+	for i := range itemStructs.Items {
+		items = append(items, readItem(archive, structFields(itemStructs.Items[i])))
+	}
+
+	return items, nil
+}
+
+func readItem(archive *remnant.SaveData, fields []remnant.Property) Item {
+	item := Item{
+		Quantity:      1,
+		EquipmentSlot: -1,
+	}
+
+	item.ID, _ = remnant.IntValue(fields, "ID")
+	item.BlueprintPath, _ = remnant.StringValue(fields, "ItemBP")
+	item.New = remnant.BoolValue(fields, "New")
+	item.Favorite = remnant.BoolValue(fields, "Favorited")
+
+	if quantity, ok := remnant.IntValue(fields, "Quantity"); ok {
+		item.Quantity = quantity
+	}
+
+	if slot, ok := remnant.IntValue(fields, "EquipmentSlotIndex"); ok && slot >= 0 {
+		item.Equipped = true
+		item.EquipmentSlot = slot
+	}
+
+	// the level is stored on the object referenced by InstanceData
+	if instanceData := remnant.FindProperty(fields, "InstanceData"); instanceData != nil {
+		if ref, ok := instanceData.Value.(remnant.ObjectProperty); ok {
+			if instance := findObject(archive, ref); instance != nil {
+				item.Level, _ = remnant.IntValue(instance.Properties, "Level")
+			}
+		}
+	}
+
+	return item
+}
@@ -0,0 +1,108 @@
+// Package profile reads the characters stored in a profile save
+// (BP_RemnantSaveGameProfile) on top of the generic property model.
+package profile
+
+import (
+	"fmt"
+	"revision-go/remnant"
+)
+
+type Character struct {
+	// position in the profile's Characters array
+	Index      int
+	ObjectPath string
+	Items      []Item
+	Build      Build
+}
+
+// ReadCharacters returns the characters of a profile save. Empty character
+// slots are skipped and characters without an inventory have no items.
+func ReadCharacters(archive *remnant.SaveArchive) ([]Character, error) {
+	saveData := &archive.Data
+	if saveData.SaveGameClassPath == nil || saveData.SaveGameClassPath.Path != remnant.REMNANT_SAVE_GAME_PROFILE {
+		return nil, fmt.Errorf("not a profile save")
+	}
+	if len(saveData.Objects) == 0 {
+		return nil, fmt.Errorf("profile save has no objects")
+	}
+
+	charactersProperty := remnant.FindProperty(saveData.Objects[0].Properties, "Characters")
+	if charactersProperty == nil {
+		return nil, fmt.Errorf("profile has no Characters property")
+	}
+	characterRefs, ok := charactersProperty.Value.(remnant.ArrayProperty)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Characters value %T", charactersProperty.Value)
+	}
+
+	characters := []Character{}
+	for i, item := range characterRefs.Items {
+		ref, ok := item.(remnant.ObjectProperty)
+		if !ok {
+			return nil, fmt.Errorf("character %d: unexpected value %T", i, item)
+		}
+
+		object := findObject(saveData, ref)
+		if object == nil {
+			continue
+		}
+
+		character, err := readCharacter(object)
+		if err != nil {
+			return nil, fmt.Errorf("character %d: %w", i, err)
+		}
+		character.Index = i
+
+		characters = append(characters, character)
+	}
+
+	return characters, nil
+}
+
+// characterArchive returns the archive nested in the Archive property of a
+// profile character object.
+func characterArchive(object *remnant.UObject) (*remnant.SaveData, error) {
+	archiveProperty := remnant.FindProperty(object.Properties, "Archive")
+	if archiveProperty == nil {
+		return nil, fmt.Errorf("%s has no Archive property", object.ObjectPath)
+	}
+
+	structProperty, ok := archiveProperty.Value.(remnant.StructProperty)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Archive value %T", archiveProperty.Value)
+	}
+
+	blob, ok := structProperty.Value.(remnant.PersistenceBlob)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Archive struct %T", structProperty.Value)
+	}
+
+	return &blob.Archive, nil
+}
+
+func readCharacter(object *remnant.UObject) (Character, error) {
+	archive, err := characterArchive(object)
+	if err != nil {
+		return Character{}, err
+	}
+
+	character := Character{
+		ObjectPath: object.ObjectPath,
+	}
+
+	// a character that was never played has no inventory yet
+	characterObject := findCharacterObject(archive)
+	if characterObject == nil {
+		character.Items = []Item{}
+		character.Build = readBuild(nil, character.Items)
+		return character, nil
+	}
+
+	character.Items, err = readInventory(archive, characterObject)
+	if err != nil {
+		return Character{}, err
+	}
+	character.Build = readBuild(characterObject.Properties, character.Items)
+
+	return character, nil
+}
@@ -0,0 +1,21 @@
+package profile
+
+import "revision-go/remnant"
+
+func findObject(saveData *remnant.SaveData, ref remnant.ObjectProperty) *remnant.UObject {
+	if ref.ObjectID < 0 || int(ref.ObjectID) >= len(saveData.Objects) {
+		return nil
+	}
+	return &saveData.Objects[ref.ObjectID]
+}
+
+// structFields returns the properties of a struct property or array item.
+func structFields(value interface{}) []remnant.Property {
+	switch v := value.(type) {
+	case remnant.StructProperty:
+		return structFields(v.Value)
+	case []remnant.Property:
+		return v
+	}
+	return nil
+}
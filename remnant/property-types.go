@@ -5,9 +5,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"revision-go/memory"
 	"revision-go/ue"
+	"strconv"
 )
 
 const (
@@ -24,6 +24,7 @@ type Property struct {
 }
 
 type ObjectProperty struct {
+	ObjectID  int32
 	ClassName string
 }
 
@@ -41,10 +42,14 @@ func readObjectProperty(r io.ReadSeeker, saveData *SaveData, raw bool) (ObjectPr
 	}
 
 	if objectIndex == -1 {
-		return ObjectProperty{}, nil
+		return ObjectProperty{ObjectID: objectIndex}, nil
+	}
+	if objectIndex < 0 || int(objectIndex) >= len(saveData.Objects) {
+		return ObjectProperty{}, fmt.Errorf("readObjectProperty: invalid object index %d", objectIndex)
 	}
 
 	return ObjectProperty{
+		ObjectID:  objectIndex,
 		ClassName: saveData.Objects[objectIndex].ObjectPath,
 	}, nil
 }
@@ -84,7 +89,10 @@ func readByteProperty(r io.ReadSeeker, saveData *SaveData, raw bool) (interface{
 	if err != nil {
 		return 0, err
 	}
-	return enumName, nil
+	return EnumProperty{
+		EnumType:  name,
+		EnumValue: enumName,
+	}, nil
 }
 
 type ArrayProperty struct {
@@ -98,6 +106,7 @@ func readArrayProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32) (int
 	if err != nil {
 		return ArrayProperty{}, err
 	}
+	traceField(r, "ElementType", elementsType)
 
 	_, err = r.Seek(1, io.SeekCurrent)
 	if err != nil {
@@ -108,6 +117,12 @@ func readArrayProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32) (int
 	if err != nil {
 		return ArrayProperty{}, err
 	}
+	traceField(r, "Count", arrayLength)
+
+	err = checkCount(r, int64(arrayLength), 1)
+	if err != nil {
+		return ArrayProperty{}, err
+	}
 
 	if elementsType == "StructProperty" {
 		arrayStructProperty, err := readArrayStructHeader(r, saveData)
@@ -115,13 +130,17 @@ func readArrayProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32) (int
 			return ArrayProperty{}, err
 		}
 		arrayStructProperty.Count = arrayLength
+		traceField(r, "StructHeader", arrayStructProperty.ElementType)
 
 		items := make([]StructProperty, arrayLength)
 		for i := 0; i < int(arrayLength); i++ {
+			pop := tracePush(r, strconv.Itoa(i))
 			value, err := readStructPropertyData(r, arrayStructProperty.ElementType, saveData)
 			if err != nil {
 				return ArrayProperty{}, err
 			}
+			traceField(r, "Value", value)
+			pop()
 			items[i] = StructProperty{
 				Name:  arrayStructProperty.ElementType,
 				Value: value,
@@ -140,25 +159,28 @@ func readArrayProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32) (int
 		Items:       make([]interface{}, arrayLength),
 	}
 	for i := 0; i < int(arrayLength); i++ {
+		pop := tracePush(r, strconv.Itoa(i))
 		elementValue, err := getPropertyValue(r, elementsType, varSize, saveData, true)
 		if err != nil {
 			return ArrayProperty{}, err
 		}
 		result.Items[i] = elementValue
+		traceField(r, "Value", elementValue)
+		pop()
 	}
 
 	return result, nil
 }
 
 func readArrayStructHeader(r io.ReadSeeker, saveData *SaveData) (ArrayStructProperty, error) {
-	// skip first 2 bytes - variable name again
-	_, err := r.Seek(2, io.SeekCurrent)
+	// skip variable name again
+	_, err := ue.ReadFName(r)
 	if err != nil {
 		return ArrayStructProperty{}, err
 	}
 
-	// skip 2 more bytes - type again (StructProperty)
-	_, err = r.Seek(2, io.SeekCurrent)
+	// skip type again (StructProperty)
+	_, err = ue.ReadFName(r)
 	if err != nil {
 		return ArrayStructProperty{}, err
 	}
@@ -234,19 +256,21 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 			if err != nil {
 				return nil, err
 			}
+			traceField(r, "Size", persistenceSize)
 
-			persistenceBytes := make([]byte, persistenceSize)
-			_, err = r.Read(persistenceBytes)
+			persistenceBytes, err := memory.ReadBytes(r, int64(persistenceSize))
 			if err != nil {
 				return nil, err
 			}
-			persistenceReader := bytes.NewReader(persistenceBytes)
+			persistenceReader := subReader(r, persistenceBytes)
 
 			if saveData.SaveGameClassPath.Path == REMNANT_SAVE_GAME_PROFILE {
+				pop := tracePush(persistenceReader, "Archive")
 				archive, err := readSaveData(persistenceReader, true, false)
 				if err != nil {
 					return nil, err
 				}
+				pop()
 
 				return PersistenceBlob{
 					Archive: archive,
@@ -257,16 +281,19 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "Version", version)
 
 			indexOffset, err := memory.ReadInt[uint32](persistenceReader)
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "IndexOffset", indexOffset)
 
 			dynamicOffset, err := memory.ReadInt[uint32](persistenceReader)
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "DynamicOffset", dynamicOffset)
 
 			_, err = persistenceReader.Seek(int64(indexOffset), io.SeekStart)
 			if err != nil {
@@ -277,6 +304,12 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "ActorsCount", infoCount)
+
+			err = checkCount(persistenceReader, int64(infoCount), int64(binary.Size(ue.FInfo{})))
+			if err != nil {
+				return nil, err
+			}
 
 			actorInfo := make([]ue.FInfo, infoCount)
 			for i := uint32(0); i < infoCount; i++ {
@@ -284,12 +317,22 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 				if err != nil {
 					return nil, err
 				}
+
+				pop := tracePush(persistenceReader, "Actors", strconv.FormatUint(actorInfo[i].UniqueID, 10))
+				traceField(persistenceReader, "Info", actorInfo[i])
+				pop()
 			}
 
 			destroyedCount, err := memory.ReadInt[uint32](persistenceReader)
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "DestroyedCount", destroyedCount)
+
+			err = checkCount(persistenceReader, int64(destroyedCount), 8)
+			if err != nil {
+				return nil, err
+			}
 
 			destroyed := make([]uint64, destroyedCount)
 			for i := uint32(0); i < destroyedCount; i++ {
@@ -297,6 +340,7 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 				if err != nil {
 					return nil, err
 				}
+				traceField(persistenceReader, "Destroyed", destroyed[i])
 			}
 
 			actors := make(map[uint64]Actor)
@@ -306,18 +350,19 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 					return nil, err
 				}
 
-				actorBytes := make([]byte, info.Size)
-				_, err = persistenceReader.Read(actorBytes)
+				actorBytes, err := memory.ReadBytes(persistenceReader, int64(info.Size))
 				if err != nil {
 					return nil, err
 				}
 
-				actorReader := bytes.NewReader(actorBytes)
+				actorReader := subReader(persistenceReader, actorBytes)
 
+				pop := tracePush(actorReader, "Actors", strconv.FormatUint(info.UniqueID, 10))
 				actors[info.UniqueID], err = readActor(actorReader)
 				if err != nil {
 					return nil, err
 				}
+				pop()
 			}
 
 			_, err = persistenceReader.Seek(int64(dynamicOffset), io.SeekStart)
@@ -329,6 +374,7 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 			if err != nil {
 				return nil, err
 			}
+			traceField(persistenceReader, "DynamicCount", dynamicCount)
 
 			for i := uint32(0); i < dynamicCount; i++ {
 				dynamicActor, err := readDynamicActor(persistenceReader)
@@ -336,8 +382,13 @@ func readStructPropertyData(r io.ReadSeeker, structName string, saveData *SaveDa
 					return nil, err
 				}
 
-				actor := actors[dynamicActor.UniqueID]
+				pop := tracePush(persistenceReader, "Actors", strconv.FormatUint(dynamicActor.UniqueID, 10))
+				traceField(persistenceReader, "DynamicData", dynamicActor)
+				pop()
+
+				actor, ok := actors[dynamicActor.UniqueID]
 				actor.DynamicData = &dynamicActor
+				actor.DynamicOnly = !ok
 				actors[dynamicActor.UniqueID] = actor
 			}
 
@@ -369,12 +420,14 @@ func readStructProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32, raw
 	if err != nil {
 		return StructProperty{}, err
 	}
+	traceField(r, "StructName", structName)
 
 	// 17 bytes, 16 GUID + padding?
 	guid, err := ue.ReadGuid(r)
 	if err != nil {
 		return StructProperty{}, err
 	}
+	traceField(r, "GUID", guid)
 	_, err = r.Seek(1, io.SeekCurrent)
 	if err != nil {
 		return StructProperty{}, err
@@ -384,6 +437,7 @@ func readStructProperty(r io.ReadSeeker, saveData *SaveData, varSize uint32, raw
 	if err != nil {
 		return StructProperty{}, err
 	}
+	traceField(r, "Value", result)
 
 	return StructProperty{
 		Name:  structName,
@@ -526,11 +580,13 @@ func readMapProperty(r io.ReadSeeker, saveData *SaveData) (MapProperty, error) {
 	if err != nil {
 		return result, fmt.Errorf("readMapProperty: %w", err)
 	}
+	traceField(r, "KeyType", result.KeyType)
 
 	result.ValueType, err = readName(r, saveData)
 	if err != nil {
 		return result, fmt.Errorf("readMapProperty: %w", err)
 	}
+	traceField(r, "ValueType", result.ValueType)
 
 	_, err = r.Seek(5, io.SeekCurrent)
 	if err != nil {
@@ -541,17 +597,27 @@ func readMapProperty(r io.ReadSeeker, saveData *SaveData) (MapProperty, error) {
 	if err != nil {
 		return result, fmt.Errorf("readMapProperty: %w", err)
 	}
+	traceField(r, "Count", mapLength)
+
+	err = checkCount(r, int64(mapLength), 1)
+	if err != nil {
+		return result, fmt.Errorf("readMapProperty: %w", err)
+	}
 
 	values := make([]MapPropertyValue, mapLength)
 	for i := 0; i < int(mapLength); i++ {
+		pop := tracePush(r, strconv.Itoa(i))
 		key, err := getPropertyValue(r, result.KeyType, 0, saveData, true)
 		if err != nil {
 			return result, fmt.Errorf("readMapProperty: %w", err)
 		}
+		traceField(r, "Key", key)
 		value, err := getPropertyValue(r, result.ValueType, 0, saveData, true)
 		if err != nil {
 			return result, fmt.Errorf("readMapProperty: %w", err)
 		}
+		traceField(r, "Value", value)
+		pop()
 
 		values[i] = struct{ Key, Value interface{} }{key, value}
 	}
@@ -571,9 +637,13 @@ type PersistenceContainer struct {
 }
 
 type Actor struct {
+	// nil if the actor was saved without a transform
 	Transform   *ue.FTransform
 	Archive     SaveData
 	DynamicData *DynamicActor
+	// set for actors that are only in the dynamic actors list and have no
+	// transform or archive of their own
+	DynamicOnly bool
 }
 
 func readActor(r io.ReadSeeker) (Actor, error) {
@@ -581,22 +651,27 @@ func readActor(r io.ReadSeeker) (Actor, error) {
 	if err != nil {
 		return Actor{}, fmt.Errorf("readActor: %w", err)
 	}
+	traceField(r, "HasTransform", hasTransform)
 
-	var transform ue.FTransform
+	var transform *ue.FTransform
 	if hasTransform != 0 {
-		transform, err = ue.ReadFTransform(r)
+		value, err := ue.ReadFTransform(r)
 		if err != nil {
 			return Actor{}, fmt.Errorf("readActor: %w", err)
 		}
+		traceField(r, "Transform", value)
+		transform = &value
 	}
 
+	pop := tracePush(r, "Archive")
 	archive, err := readSaveData(r, false, false)
 	if err != nil {
 		return Actor{}, fmt.Errorf("readActor: %w", err)
 	}
+	pop()
 
 	return Actor{
-		Transform: &transform,
+		Transform: transform,
 		Archive:   archive,
 	}, nil
 }
@@ -661,6 +736,11 @@ func readName(r io.Reader, saveData *SaveData) (string, error) {
 		return "", fmt.Errorf("readNameProperty: invalid index %d", fName.Index)
 	}
 
+	// same as FName::ToString, numbered names are stored with number + 1
+	if fName.Number != 0 {
+		return fmt.Sprintf("%s_%d", saveData.NamesTable[fName.Index], fName.Number-1), nil
+	}
+
 	return saveData.NamesTable[fName.Index], nil
 }
 
@@ -694,8 +774,7 @@ func readStrProperty(r io.ReadSeeker, raw bool) (string, error) {
 	if strLength == 0 {
 		return "", nil
 	}
-	strData := make([]byte, strLength)
-	_, err = r.Read(strData)
+	strData, err := memory.ReadBytes(r, int64(strLength))
 	if err != nil {
 		return "", fmt.Errorf("readStrProperty: %w", err)
 	}
@@ -763,7 +842,7 @@ func getPropertyValue(r io.ReadSeeker, varType string, varSize uint32, saveData
 
 	case "MapProperty":
 		if raw {
-			log.Fatal("Raw map property is not supported yet")
+			return nil, fmt.Errorf("raw map property is not supported yet")
 		}
 		return readMapProperty(r, saveData)
 
@@ -806,28 +885,37 @@ func readProperty(r io.ReadSeeker, saveData *SaveData) (*Property, error) {
 	}
 
 	if varName == "None" {
+		traceField(r, "End", varName)
 		return nil, nil
 	}
 
+	defer tracePush(r, varName)()
+	traceField(r, "Name", varName)
+
 	varType, err := readName(r, saveData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read variable type index: %w", err)
 	}
+	traceField(r, "Type", varType)
 
 	varSize, err := memory.ReadInt[uint32](r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read variable size: %w", err)
 	}
+	traceField(r, "Size", varSize)
 
 	index, err := memory.ReadInt[uint32](r)
 	if err != nil {
 		return nil, err
 	}
+	traceField(r, "Index", index)
+	if index != 0 {
+		traceRename(r, propertyKey(&Property{Name: varName, Index: index}))
+	}
 
 	var value interface{}
 	if varName == "FowVisitedCoordinates" {
-		value = make([]byte, varSize+19)
-		_, err := r.Read(value.([]byte))
+		value, err = memory.ReadBytes(r, int64(varSize)+19)
 		if err != nil {
 			return nil, err
 		}
@@ -837,6 +925,7 @@ func readProperty(r io.ReadSeeker, saveData *SaveData) (*Property, error) {
 			return nil, fmt.Errorf("failed to read variable data (%s %s %d): %w", varName, varType, varSize, err)
 		}
 	}
+	traceField(r, "Value", value)
 
 	return &Property{
 		Name:  varName,
@@ -862,3 +951,32 @@ func readProperties(r io.ReadSeeker, saveData *SaveData) ([]Property, error) {
 
 	return result, nil
 }
+
+// checkCount returns an error if count items of at least itemSize bytes each
+// do not fit in the rest of r. Counts read from a save are checked before
+// anything is allocated for them, so that a corrupt count cannot make the
+// reader claim gigabytes of memory.
+func checkCount(r io.Seeker, count int64, itemSize int64) error {
+	if t, ok := r.(*traceReader); ok {
+		// seeking the trace reader would move its mark
+		r = t.ReadSeeker
+	}
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	_, err = r.Seek(pos, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	if count < 0 || count*itemSize > end-pos {
+		return fmt.Errorf("count %d does not fit in the %d bytes left", count, end-pos)
+	}
+	return nil
+}
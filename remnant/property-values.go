@@ -0,0 +1,74 @@
+package remnant
+
+// FindProperty returns the first property with the given name.
+func FindProperty(properties []Property, name string) *Property {
+	for i := range properties {
+		if properties[i].Name == name {
+			return &properties[i]
+		}
+	}
+	return nil
+}
+
+func FindComponent(object *UObject, key string) *Component {
+	for i := range object.Components {
+		if object.Components[i].ComponentKey == key {
+			return &object.Components[i]
+		}
+	}
+	return nil
+}
+
+// IntValue returns the value of an int or byte property.
+func IntValue(properties []Property, name string) (int32, bool) {
+	property := FindProperty(properties, name)
+	if property == nil {
+		return 0, false
+	}
+
+	switch v := property.Value.(type) {
+	case int32:
+		return v, true
+	case uint8:
+		return int32(v), true
+	}
+	return 0, false
+}
+
+// BoolValue returns the value of a bool or byte property, false if missing.
+func BoolValue(properties []Property, name string) bool {
+	property := FindProperty(properties, name)
+	if property == nil {
+		return false
+	}
+
+	switch v := property.Value.(type) {
+	case bool:
+		return v
+	case uint8:
+		return v != 0
+	}
+	return false
+}
+
+// StringValue returns names, strings, enum values, object paths and soft
+// paths.
+func StringValue(properties []Property, name string) (string, bool) {
+	property := FindProperty(properties, name)
+	if property == nil {
+		return "", false
+	}
+
+	switch v := property.Value.(type) {
+	case string:
+		return v, true
+	case EnumProperty:
+		return v.EnumValue, true
+	case ObjectProperty:
+		return v.ClassName, v.ObjectID >= 0
+	case StructProperty:
+		path, ok := v.Value.(string)
+		return path, ok
+	}
+	return "", false
+}
@@ -0,0 +1,50 @@
+package remnant
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DecodeSave parses the contents of a save file. Together with EncodeSave it
+// is the entry point for callers that have no file system, such as the
+// WebAssembly build. Counts and lengths in data are checked against the data
+// they count before anything is allocated for them, so a malformed save is
+// rejected with an error rather than exhausting memory.
+func DecodeSave(data []byte) (SaveArchive, error) {
+	decoded, err := DecodeData(bytes.NewReader(data))
+	if err != nil {
+		return SaveArchive{}, fmt.Errorf("invalid save file: %w", err)
+	}
+
+	archive, err := ReadSaveArchive(bytes.NewReader(decoded))
+	if err != nil {
+		return SaveArchive{}, fmt.Errorf("failed to parse save: %w", err)
+	}
+
+	return archive, nil
+}
+
+// EncodeSave is the counterpart of DecodeSave: it returns the contents of the
+// save file for archive.
+func EncodeSave(archive *SaveArchive) (result []byte, err error) {
+	// archives built by callers may hold values the writer does not expect
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, err = nil, fmt.Errorf("failed to encode save: %v", recovered)
+		}
+	}()
+
+	var decoded bytes.Buffer
+	err = WriteSaveArchive(&decoded, archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode save: %w", err)
+	}
+
+	var data bytes.Buffer
+	err = EncodeData(&data, decoded.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Bytes(), nil
+}
@@ -0,0 +1,206 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"revision-go/ue"
+	"testing"
+)
+
+func testArchive() *SaveArchive {
+	classPath := ue.FTopLevelAssetPath{Path: "/Game/Test/BP_TestSave", Name: "BP_TestSave_C"}
+
+	return &SaveArchive{
+		Header: SaveHeader{SaveGameFileVersion: 9, BuildNumber: 1},
+		Data: SaveData{
+			PackageVersion:    &PackageVersion{UE4Version: 1, UE5Version: 2},
+			SaveGameClassPath: &classPath,
+			Objects: []UObject{
+				{
+					ObjectID:   0,
+					WasLoaded:  true,
+					ObjectPath: classPath.Path,
+					Properties: []Property{
+						{Name: "Level", Type: "IntProperty", Value: int32(42)},
+						{Name: "Seed", Type: "UInt64Property", Value: uint64(1<<63 + 1)},
+						{Name: "Title", Type: "StrProperty", Value: "Test save"},
+						{Name: "Hardcore", Type: "BoolProperty", Value: true},
+						{Name: "Character", Type: "ObjectProperty", Value: ObjectProperty{ObjectID: 1, ClassName: "/Game/Test/Character"}},
+						{Name: "Tags", Type: "ArrayProperty", Value: ArrayProperty{
+							Count:       2,
+							ElementType: "NameProperty",
+							Items:       []interface{}{"First", "Second"},
+						}},
+					},
+				},
+				{
+					ObjectID:   1,
+					ObjectPath: "/Game/Test/Character",
+					LoadedData: &UObjectLoadedData{Name: "Character", OuterID: 0},
+					Properties: []Property{
+						{Name: "Name", Type: "NameProperty", Value: "Archon"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEncodeDecodeSave(t *testing.T) {
+	original := testArchive()
+
+	data, err := EncodeSave(original)
+	if err != nil {
+		t.Fatalf("EncodeSave: %v", err)
+	}
+
+	archive, err := DecodeSave(data)
+	if err != nil {
+		t.Fatalf("DecodeSave: %v", err)
+	}
+
+	if len(archive.Data.Objects) != len(original.Data.Objects) {
+		t.Fatalf("decoded %d objects, want %d", len(archive.Data.Objects), len(original.Data.Objects))
+	}
+	for i, object := range original.Data.Objects {
+		decoded := archive.Data.Objects[i]
+		if decoded.ObjectPath != object.ObjectPath {
+			t.Errorf("object %d: path %q, want %q", i, decoded.ObjectPath, object.ObjectPath)
+		}
+		if len(decoded.Properties) != len(object.Properties) {
+			t.Errorf("object %d: %d properties, want %d", i, len(decoded.Properties), len(object.Properties))
+			continue
+		}
+		for j, property := range object.Properties {
+			got := decoded.Properties[j]
+			if got.Name != property.Name || got.Type != property.Type || !reflect.DeepEqual(got.Value, property.Value) {
+				t.Errorf("object %d: property %s %s = %#v, want %#v", i, got.Name, got.Type, got.Value, property.Value)
+			}
+		}
+	}
+
+	// a decoded save encodes to the same bytes
+	again, err := EncodeSave(&archive)
+	if err != nil {
+		t.Fatalf("EncodeSave of the decoded save: %v", err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("encoding the decoded save changed it")
+	}
+
+	decodedAgain, err := DecodeSave(again)
+	if err != nil {
+		t.Fatalf("DecodeSave of the encoded save: %v", err)
+	}
+	if !reflect.DeepEqual(decodedAgain, archive) {
+		t.Errorf("decoding the encoded save changed it")
+	}
+}
+
+func TestDecodeSaveRejectsMalformedInput(t *testing.T) {
+	valid, err := EncodeSave(testArchive())
+	if err != nil {
+		t.Fatalf("EncodeSave: %v", err)
+	}
+
+	corrupted := append([]byte{}, valid...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	// the compressed size of the first chunk, after the crc, size and
+	// version of the save and the tag, chunk size and compressor of the chunk
+	hugeChunk := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint64(hugeChunk[12+8+8+1:], 1<<40)
+
+	cases := map[string][]byte{
+		"empty":      {},
+		"garbage":    []byte("this is not a save file at all"),
+		"header":     valid[:12],
+		"truncated":  valid[:len(valid)-10],
+		"corrupted":  corrupted,
+		"huge chunk": hugeChunk,
+	}
+	for name, data := range cases {
+		_, err := DecodeSave(data)
+		if err == nil {
+			t.Errorf("%s: DecodeSave succeeded", name)
+		}
+	}
+}
+
+// Every prefix of the decompressed data of a valid save is a save whose
+// container is intact but whose contents end early; none of them may be
+// accepted or crash the reader.
+func TestDecodeSaveRejectsTruncatedContents(t *testing.T) {
+	var decoded bytes.Buffer
+	err := WriteSaveArchive(&decoded, testArchive())
+	if err != nil {
+		t.Fatalf("WriteSaveArchive: %v", err)
+	}
+
+	for size := 12; size < decoded.Len(); size++ {
+		content := append([]byte{}, decoded.Bytes()[:size]...)
+
+		var data bytes.Buffer
+		err := EncodeData(&data, content)
+		if err != nil {
+			t.Fatalf("EncodeData: %v", err)
+		}
+
+		_, err = DecodeSave(data.Bytes())
+		if err == nil {
+			t.Errorf("DecodeSave accepted the first %d of %d bytes", size, decoded.Len())
+		}
+	}
+}
+
+// Counts and lengths are read from the save before what they count, a save
+// that declares more than it holds must be rejected without allocating
+// memory for it.
+func TestDecodeSaveRejectsHugeCounts(t *testing.T) {
+	var decoded bytes.Buffer
+	err := WriteSaveArchive(&decoded, testArchive())
+	if err != nil {
+		t.Fatalf("WriteSaveArchive: %v", err)
+	}
+	_, trace, err := TraceSaveArchive(bytes.NewReader(decoded.Bytes()))
+	if err != nil {
+		t.Fatalf("TraceSaveArchive: %v", err)
+	}
+
+	fields := []struct {
+		path  string
+		field string
+	}{
+		{"/Objects/0/Properties/Tags", "Count"},
+		{"/Objects/0", "Length"},
+		{"", "ObjectsCount"},
+		{"", "NamesCount"},
+	}
+	for _, f := range fields {
+		var entry *TraceEntry
+		for i := range trace.Entries {
+			if trace.Entries[i].Path.String() == f.path && trace.Entries[i].Field == f.field {
+				entry = &trace.Entries[i]
+			}
+		}
+		if entry == nil {
+			t.Fatalf("%s %s is not traced", f.path, f.field)
+		}
+
+		// the field is the last 4 bytes of its entry, after any padding
+		content := append([]byte{}, decoded.Bytes()...)
+		binary.LittleEndian.PutUint32(content[entry.Offset+entry.Length-4:], 0x7fffffff)
+
+		var data bytes.Buffer
+		err := EncodeData(&data, content)
+		if err != nil {
+			t.Fatalf("EncodeData: %v", err)
+		}
+
+		_, err = DecodeSave(data.Bytes())
+		if err == nil {
+			t.Errorf("%s %s: DecodeSave accepted a count of 0x7fffffff", f.path, f.field)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package remnant
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const PROFILE_FILE_NAME = "profile.sav"
+
+var worldSaveFileName = regexp.MustCompile(`^save_(\d+)\.sav$`)
+
+// SaveSlot is a world save and the profile character it belongs to.
+type SaveSlot struct {
+	// N of save_N.sav, the position of the character in the profile
+	Index   int
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Header  SaveHeader
+	// object path of the profile character, empty if the profile has no
+	// character in this slot
+	CharacterObjectPath string
+	// error reading the save, if any
+	Error string
+}
+
+type SaveDir struct {
+	Dir         string
+	ProfilePath string
+	// nil if there is no profile or it could not be read
+	Profile      *SaveArchive
+	ProfileError string
+	Slots        []SaveSlot
+}
+
+// profileCharacters returns the object paths of the profile characters by
+// slot, with an empty path for empty slots.
+func profileCharacters(profile *SaveArchive) []string {
+	if len(profile.Data.Objects) == 0 {
+		return nil
+	}
+
+	property := FindProperty(profile.Data.Objects[0].Properties, "Characters")
+	if property == nil {
+		return nil
+	}
+	array, ok := property.Value.(ArrayProperty)
+	if !ok {
+		return nil
+	}
+
+	characters := make([]string, len(array.Items))
+	for i, item := range array.Items {
+		if ref, ok := item.(ObjectProperty); ok && ref.ObjectID >= 0 {
+			characters[i] = ref.ClassName
+		}
+	}
+	return characters
+}
+
+func readSaveFileHeader(filePath string) (SaveHeader, error) {
+	data, err := ReadData(filePath)
+	if err != nil {
+		return SaveHeader{}, err
+	}
+	return readSaveHeader(bytes.NewReader(data))
+}
+
+// OpenSaveDir indexes the profile and world saves of a save directory. Saves
+// that cannot be read are reported in the Error fields instead of failing the
+// whole directory.
+func OpenSaveDir(dir string) (*SaveDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	saveDir := &SaveDir{
+		Dir:   dir,
+		Slots: []SaveSlot{},
+	}
+
+	var characters []string
+	profilePath := filepath.Join(dir, PROFILE_FILE_NAME)
+	if _, err := os.Stat(profilePath); err == nil {
+		saveDir.ProfilePath = profilePath
+
+		profile, err := readSaveArchiveFile(profilePath)
+		if err != nil {
+			saveDir.ProfileError = err.Error()
+		} else {
+			saveDir.Profile = &profile
+			characters = profileCharacters(&profile)
+		}
+	}
+
+	for _, entry := range entries {
+		match := worldSaveFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		index, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+
+		slot := SaveSlot{
+			Index: index,
+			Path:  filepath.Join(dir, entry.Name()),
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		slot.Size = info.Size()
+		slot.ModTime = info.ModTime()
+
+		if index < len(characters) {
+			slot.CharacterObjectPath = characters[index]
+		}
+
+		slot.Header, err = readSaveFileHeader(slot.Path)
+		if err != nil {
+			slot.Error = err.Error()
+		}
+
+		saveDir.Slots = append(saveDir.Slots, slot)
+	}
+
+	sort.Slice(saveDir.Slots, func(i, j int) bool { return saveDir.Slots[i].Index < saveDir.Slots[j].Index })
+
+	if saveDir.ProfilePath == "" && len(saveDir.Slots) == 0 {
+		return nil, fmt.Errorf("no saves found in %s", dir)
+	}
+
+	return saveDir, nil
+}
+
+func readSaveArchiveFile(filePath string) (SaveArchive, error) {
+	data, err := ReadData(filePath)
+	if err != nil {
+		return SaveArchive{}, err
+	}
+	return ReadSaveArchive(bytes.NewReader(data))
+}
@@ -0,0 +1,92 @@
+package remnant
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+)
+
+const REMNANT_2_STEAM_APP_ID = "1282100"
+
+var steamLibraryPath = regexp.MustCompile(`"path"\s+"([^"]+)"`)
+
+// steamLibraries returns the Steam installation directories and the extra
+// libraries listed in their libraryfolders.vdf.
+func steamLibraries(home string) []string {
+	roots := []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".steam", "root"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+
+	libraries := []string{}
+	for _, root := range roots {
+		libraries = append(libraries, root)
+
+		data, err := os.ReadFile(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			continue
+		}
+		for _, match := range steamLibraryPath.FindAllSubmatch(data, -1) {
+			libraries = append(libraries, string(match[1]))
+		}
+	}
+
+	return libraries
+}
+
+// saveRoots returns the Remnant2 folders under Saved Games that may exist on
+// this system: the Windows one, or the ones inside Proton prefixes on Linux.
+func saveRoots() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{filepath.Join(home, "Saved Games", "Remnant2")}
+	}
+
+	roots := []string{}
+	for _, library := range steamLibraries(home) {
+		roots = append(roots, filepath.Join(
+			library, "steamapps", "compatdata", REMNANT_2_STEAM_APP_ID,
+			"pfx", "drive_c", "users", "steamuser", "Saved Games", "Remnant2",
+		))
+	}
+	return roots
+}
+
+// FindSaveDirs returns the existing save directories of the common Steam
+// locations, one per Steam account. On Linux, Proton prefixes of all Steam
+// libraries are searched.
+func FindSaveDirs() []string {
+	dirs := []string{}
+	seen := map[string]bool{}
+
+	for _, root := range saveRoots() {
+		profiles, err := filepath.Glob(filepath.Join(root, "Steam", "*", PROFILE_FILE_NAME))
+		if err != nil {
+			continue
+		}
+
+		for _, profile := range profiles {
+			dir := filepath.Dir(profile)
+
+			resolved, err := filepath.EvalSymlinks(dir)
+			if err != nil {
+				resolved = dir
+			}
+			if seen[resolved] {
+				continue
+			}
+			seen[resolved] = true
+
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
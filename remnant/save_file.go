@@ -9,6 +9,7 @@ import (
 	"hash/crc32"
 	"io"
 	"os"
+	"revision-go/memory"
 )
 
 type CompressedChunkHeader struct {
@@ -44,17 +45,22 @@ const (
 	CompressorZlib = 3
 )
 
-func decompressData(data []byte) ([]byte, error) {
-	const maxCompressedSize = 20 * 1024 * 1024   // 20 MB
-	const maxDecompressedSize = 40 * 1024 * 1024 // 40 MB
+const (
+	maxCompressedSize   = 20 * 1024 * 1024 // 20 MB
+	maxDecompressedSize = 40 * 1024 * 1024 // 40 MB
+	// limit of all chunks together, chunks compress well enough that a small
+	// file could otherwise decompress to gigabytes
+	maxSaveDataSize = 256 * 1024 * 1024 // 256 MB
+)
 
+func decompressData(data []byte) ([]byte, error) {
 	if len(data) > maxCompressedSize {
 		return nil, fmt.Errorf("compressed data is too large")
 	}
 
 	zr, err := zlib.NewReader(bytes.NewReader(data))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer zr.Close()
 
@@ -69,15 +75,9 @@ func decompressData(data []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func readSave(filePath string) (*SaveFile, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
+func readSave(file io.Reader) (*SaveFile, error) {
 	var dataCrc32 uint32
-	err = binary.Read(file, binary.LittleEndian, &dataCrc32)
+	err := binary.Read(file, binary.LittleEndian, &dataCrc32)
 	if err != nil {
 		return nil, err
 	}
@@ -116,8 +116,10 @@ func readSave(filePath string) (*SaveFile, error) {
 			return nil, fmt.Errorf("unsupported compressor")
 		}
 
-		data := make([]byte, compressedChunkHeader.CompressedSize)
-		err = binary.Read(file, binary.LittleEndian, &data)
+		if compressedChunkHeader.CompressedSize > maxCompressedSize {
+			return nil, fmt.Errorf("compressed data is too large")
+		}
+		data, err := memory.ReadBytes(file, int64(compressedChunkHeader.CompressedSize))
 		if err != nil {
 			return nil, err
 		}
@@ -154,11 +156,17 @@ func decompressChunks(saveFile *SaveFile) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to decompress chunk: %w", err)
 		}
+		if result.Len()+len(buf) > maxSaveDataSize {
+			return nil, fmt.Errorf("decompressed save data is too large")
+		}
 
 		result.Write(buf)
 	}
 
 	data := result.Bytes()
+	if len(data) < 12 {
+		return nil, fmt.Errorf("save data is too short")
+	}
 
 	binary.LittleEndian.PutUint32(data[8:], saveFile.Version)
 
@@ -170,10 +178,131 @@ func decompressChunks(saveFile *SaveFile) ([]byte, error) {
 }
 
 func ReadData(filePath string) ([]byte, error) {
-	saveFile, err := readSave(filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return DecodeData(file)
+}
+
+// DecodeData is ReadData for a save file that is not on disk.
+func DecodeData(r io.Reader) ([]byte, error) {
+	saveFile, err := readSave(r)
 	if err != nil {
 		return nil, err
 	}
 
 	return decompressChunks(saveFile)
 }
+
+func compressData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := zlib.NewWriter(&buf)
+	_, err := zw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compressChunks is the counterpart of decompressChunks. It fills in the crc
+// and size of the decompressed data and splits everything after them into
+// compressed chunks.
+func compressChunks(data []byte) (*SaveFile, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("save data is too short")
+	}
+
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+	dataCrc32 := crc32.Checksum(data[4:], crc32.MakeTable(crc32.IEEE))
+	binary.LittleEndian.PutUint32(data[0:], dataCrc32)
+
+	chunks := []CompressedSaveChunk{}
+	content := data[8:]
+	for len(content) > 0 {
+		size := len(content)
+		if size > LOADING_COMPRESSION_CHUNK_SIZE {
+			size = LOADING_COMPRESSION_CHUNK_SIZE
+		}
+
+		compressed, err := compressData(content[:size])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress chunk: %w", err)
+		}
+
+		chunks = append(chunks, CompressedSaveChunk{
+			Header: CompressedChunkHeader{
+				PackageFileTag:               ARCHIVE_V2_HEADER_TAG,
+				LoadingCompressionChunkSize:  LOADING_COMPRESSION_CHUNK_SIZE,
+				Compressor:                   CompressorZlib,
+				CompressedSize:               uint64(len(compressed)),
+				LoadingCompressionChunkSize2: uint64(size),
+				CompressedSize2:              uint64(len(compressed)),
+				LoadingCompressionChunkSize3: uint64(size),
+			},
+			Data: compressed,
+		})
+		content = content[size:]
+	}
+
+	return &SaveFile{
+		Crc32:       dataCrc32,
+		ContentSize: uint32(len(data)),
+		Version:     binary.LittleEndian.Uint32(data[8:]),
+		Chunks:      chunks,
+	}, nil
+}
+
+func writeSave(w io.Writer, saveFile *SaveFile) error {
+	var buf bytes.Buffer
+
+	for _, value := range []uint32{saveFile.Crc32, saveFile.ContentSize, saveFile.Version} {
+		err := binary.Write(&buf, binary.LittleEndian, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, chunk := range saveFile.Chunks {
+		err := binary.Write(&buf, binary.LittleEndian, chunk.Header)
+		if err != nil {
+			return err
+		}
+		buf.Write(chunk.Data)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteData is the counterpart of ReadData: it compresses decompressed save
+// data, such as the output of WriteSaveArchive, into a save file.
+func WriteData(filePath string, data []byte) error {
+	var buf bytes.Buffer
+
+	err := EncodeData(&buf, data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, buf.Bytes(), 0644)
+}
+
+// EncodeData is WriteData for a save file that is not written to disk.
+func EncodeData(w io.Writer, data []byte) error {
+	saveFile, err := compressChunks(data)
+	if err != nil {
+		return err
+	}
+
+	return writeSave(w, saveFile)
+}
@@ -0,0 +1,269 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// how a field is converted from and to its property
+const (
+	fieldValue            = "value"
+	fieldEnum             = "enum"
+	fieldStruct           = "struct"
+	fieldStructValue      = "struct value"
+	fieldVariables        = "variables"
+	fieldArray            = "array"
+	fieldStructArray      = "struct array"
+	fieldStructValueArray = "struct value array"
+	fieldMap              = "map"
+	fieldRaw              = "raw"
+)
+
+func (s *Schema) fieldKind(p *Property) string {
+	switch p.Type {
+	case "ArrayProperty":
+		if p.ElementType != "StructProperty" {
+			return fieldArray
+		}
+		if s.Find(KindStruct, p.StructName) != nil {
+			return fieldStructArray
+		}
+		return fieldStructValueArray
+
+	case "MapProperty":
+		return fieldMap
+
+	case "EnumProperty":
+		return fieldEnum
+
+	case "ByteProperty":
+		if len(p.Values) > 0 {
+			return fieldEnum
+		}
+
+	case "StructProperty":
+		if _, ok := leafStructGoTypes[p.StructName]; ok {
+			return fieldStructValue
+		}
+		if s.Find(KindStruct, p.StructName) != nil {
+			return fieldStruct
+		}
+		return fieldRaw
+	}
+
+	if _, ok := scalarGoTypes[p.Type]; ok {
+		return fieldValue
+	}
+	if s.Find(KindStruct, p.StructName) != nil {
+		return fieldVariables
+	}
+	return fieldRaw
+}
+
+func optional(t *Type, p *Property) bool {
+	return p.Count < t.Count
+}
+
+// writeFromProperty writes the statements that set target from property,
+// leaving errors in err.
+func (s *Schema) writeFromProperty(w io.Writer, p *Property, target string, names map[*Type]string) {
+	goType := s.GoType(p, names)
+	elementType := strings.TrimPrefix(goType, "[]")
+
+	switch s.fieldKind(p) {
+	case fieldValue:
+		fmt.Fprintf(w, "%s, err = typed.Value[%s](property)\n", target, goType)
+
+	case fieldEnum:
+		fmt.Fprintf(w, "%s, err = typed.Enum(property)\n", target)
+
+	case fieldStruct, fieldVariables:
+		fmt.Fprintf(w, "var properties []remnant.Property\n")
+		fmt.Fprintf(w, "properties, err = typed.Struct(property)\n")
+		fmt.Fprintf(w, "if err == nil {\nerr = %s.FromProperties(properties)\n}\n", target)
+
+	case fieldStructValue:
+		fmt.Fprintf(w, "%s, err = typed.StructValue[%s](property)\n", target, goType)
+
+	case fieldArray:
+		fmt.Fprintf(w, "%s, err = typed.Items[%s](property)\n", target, elementType)
+
+	case fieldStructArray:
+		fmt.Fprintf(w, "var items [][]remnant.Property\n")
+		fmt.Fprintf(w, "items, err = typed.StructItems[[]remnant.Property](property)\n")
+		fmt.Fprintf(w, "%s = make(%s, len(items))\n", target, goType)
+		fmt.Fprintf(w, "for j := 0; j < len(items) && err == nil; j++ {\nerr = %s[j].FromProperties(items[j])\n}\n", target)
+
+	case fieldStructValueArray:
+		fmt.Fprintf(w, "%s, err = typed.StructItems[%s](property)\n", target, elementType)
+
+	case fieldMap:
+		fmt.Fprintf(w, "%s, err = typed.MapEntries(property)\n", target)
+
+	case fieldRaw:
+		fmt.Fprintf(w, "%s = property.Value\n", target)
+	}
+}
+
+// writeToProperty writes the statements that set the property from value.
+func (s *Schema) writeToProperty(w io.Writer, p *Property, value string) {
+	switch s.fieldKind(p) {
+	case fieldValue, fieldRaw:
+		fmt.Fprintf(w, "b.Set(%q, %q, %s)\n", p.Name, p.Type, value)
+
+	case fieldEnum:
+		fmt.Fprintf(w, "b.SetEnum(%q, %q, %s)\n", p.Name, p.Type, value)
+
+	case fieldStruct:
+		fmt.Fprintf(w, "b.SetStruct(%q, %q, %s.ToProperties())\n", p.Name, p.StructName, value)
+
+	case fieldVariables:
+		fmt.Fprintf(w, "b.SetVariables(%q, %q, %s.ToProperties())\n", p.Name, p.StructName, value)
+
+	case fieldStructValue:
+		fmt.Fprintf(w, "b.SetStruct(%q, %q, %s)\n", p.Name, p.StructName, value)
+
+	case fieldArray:
+		fmt.Fprintf(w, "b.SetArray(%q, %q, typed.ToItems(%s))\n", p.Name, p.ElementType, value)
+
+	case fieldStructArray:
+		fmt.Fprintf(w, "items := make([]interface{}, len(%s))\n", value)
+		fmt.Fprintf(w, "for j := range %s {\nitems[j] = %s[j].ToProperties()\n}\n", value, value)
+		fmt.Fprintf(w, "b.SetStructArray(%q, %q, items)\n", p.Name, p.StructName)
+
+	case fieldStructValueArray:
+		fmt.Fprintf(w, "b.SetStructArray(%q, %q, typed.ToItems(%s))\n", p.Name, p.StructName, value)
+
+	case fieldMap:
+		fmt.Fprintf(w, "b.SetMap(%q, %q, %q, %s)\n", p.Name, p.KeyType, p.ValueType, value)
+	}
+}
+
+func (s *Schema) writeType(w io.Writer, t *Type, names map[*Type]string) {
+	name := names[t]
+	fields := FieldNames(t)
+
+	fmt.Fprintf(w, "// %s is the %s %s.\n", name, t.Kind, t.Name)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, p := range t.Properties {
+		goType := s.GoType(p, names)
+		if optional(t, p) && goType != "interface{}" {
+			goType = "*" + goType
+		}
+		fmt.Fprintf(w, "%s %s // %s\n", fields[p], goType, propertyComment(t, p))
+	}
+	fmt.Fprint(w, "\n// properties read by FromProperties, updated by ToProperties\nraw []remnant.Property\n}\n\n")
+
+	fmt.Fprintf(w, "// FromProperties sets the fields of v from a property list. Properties that\n")
+	fmt.Fprintf(w, "// are not fields are kept for ToProperties.\n")
+	fmt.Fprintf(w, "func (v *%s) FromProperties(properties []remnant.Property) error {\n", name)
+	fmt.Fprintf(w, "*v = %s{raw: properties}\n\n", name)
+	fmt.Fprint(w, "for i := range properties {\nproperty := &properties[i]\nif property.Index != 0 {\ncontinue\n}\n\n")
+	fmt.Fprint(w, "var err error\nswitch property.Name {\n")
+	for _, p := range t.Properties {
+		fmt.Fprintf(w, "case %q:\n", p.Name)
+		if optional(t, p) && s.fieldKind(p) != fieldRaw {
+			fmt.Fprintf(w, "var value %s\n", s.GoType(p, names))
+			s.writeFromProperty(w, p, "value", names)
+			fmt.Fprintf(w, "v.%s = &value\n", fields[p])
+		} else {
+			s.writeFromProperty(w, p, "v."+fields[p], names)
+		}
+	}
+	io.WriteString(w, "}\nif err != nil {\nreturn fmt.Errorf(\"%s: %w\", property.Name, err)\n}\n}\n\nreturn nil\n}\n\n")
+
+	fmt.Fprintf(w, "// ToProperties returns the property list v was read from with the values of\n")
+	fmt.Fprintf(w, "// its fields. Unset optional fields are removed, other new fields appended.\n")
+	fmt.Fprintf(w, "func (v *%s) ToProperties() []remnant.Property {\n", name)
+	fmt.Fprint(w, "b := typed.NewBuilder(v.raw)\n")
+	for _, p := range t.Properties {
+		field := "v." + fields[p]
+		switch {
+		case optional(t, p) && s.fieldKind(p) == fieldRaw:
+			fmt.Fprintf(w, "if %s != nil {\n", field)
+			s.writeToProperty(w, p, field)
+			fmt.Fprintf(w, "} else {\nb.Remove(%q)\n}\n", p.Name)
+		case optional(t, p):
+			fmt.Fprintf(w, "if %s != nil {\nvalue := *%s\n", field, field)
+			s.writeToProperty(w, p, "value")
+			fmt.Fprintf(w, "} else {\nb.Remove(%q)\n}\n", p.Name)
+		case s.fieldKind(p) == fieldStructArray:
+			fmt.Fprint(w, "{\n")
+			s.writeToProperty(w, p, field)
+			fmt.Fprint(w, "}\n")
+		default:
+			s.writeToProperty(w, p, field)
+		}
+	}
+	fmt.Fprint(w, "return b.Properties()\n}\n\n")
+}
+
+// selectTypes returns the types with the given names, schema or Go, and the
+// struct types they use. Without names, all types with properties are
+// returned.
+func (s *Schema) selectTypes(selected []string, names map[*Type]string) ([]*Type, error) {
+	if len(selected) == 0 {
+		result := []*Type{}
+		for _, t := range s.Types {
+			if len(t.Properties) > 0 {
+				result = append(result, t)
+			}
+		}
+		return result, nil
+	}
+
+	included := map[*Type]bool{}
+	var include func(t *Type)
+	include = func(t *Type) {
+		if included[t] {
+			return
+		}
+		included[t] = true
+		for _, p := range t.Properties {
+			if used := s.Find(KindStruct, p.StructName); used != nil {
+				include(used)
+			}
+		}
+	}
+
+	for _, name := range selected {
+		found := false
+		for _, t := range s.Types {
+			if t.Name == name || names[t] == name {
+				include(t)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("type %s is not in the schema", name)
+		}
+	}
+
+	result := []*Type{}
+	for _, t := range s.Types {
+		if included[t] {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// WriteGo writes typed structs with FromProperties and ToProperties
+// converters for the selected types, see selectTypes.
+func (s *Schema) WriteGo(w io.Writer, pkg string, selected []string) error {
+	names := s.TypeNames()
+
+	types, err := s.selectTypes(selected, names)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for _, t := range types {
+		s.writeType(&body, t, names)
+	}
+
+	return writeGoFile(w, "// Code generated by revision codegen. DO NOT EDIT.", pkg, body.Bytes())
+}
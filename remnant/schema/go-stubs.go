@@ -0,0 +1,199 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// leafStructGoTypes are the Go types of structs that are read as a single
+// value rather than as properties.
+var leafStructGoTypes = map[string]string{
+	"Vector":         "ue.FVector",
+	"Guid":           "ue.FGuid",
+	"DateTime":       "int64",
+	"Timespan":       "int64",
+	"SoftClassPath":  "string",
+	"SoftObjectPath": "string",
+}
+
+var scalarGoTypes = map[string]string{
+	"IntProperty":    "int32",
+	"Int16Property":  "int16",
+	"Int64Property":  "int64",
+	"UInt16Property": "uint16",
+	"UInt32Property": "uint32",
+	"UInt64Property": "uint64",
+	"FloatProperty":  "float32",
+	// as spelled by the variables reader
+	"FloatProeprty":      "float32",
+	"DoubleProperty":     "float64",
+	"BoolProperty":       "bool",
+	"ByteProperty":       "uint8",
+	"StrProperty":        "string",
+	"NameProperty":       "string",
+	"SoftObjectProperty": "string",
+	"SoftClassPath":      "string",
+	"EnumProperty":       "string",
+	"ObjectProperty":     "remnant.ObjectProperty",
+	"TextProperty":       "remnant.TextProperty",
+}
+
+func (s *Schema) goValueType(valueType string, structName string, names map[*Type]string) string {
+	if goType, ok := scalarGoTypes[valueType]; ok {
+		return goType
+	}
+
+	if valueType == "StructProperty" {
+		if goType, ok := leafStructGoTypes[structName]; ok {
+			return goType
+		}
+	}
+
+	// structs and the variables of variables components
+	if t := s.Find(KindStruct, structName); t != nil {
+		return names[t]
+	}
+
+	return "interface{}"
+}
+
+// GoType returns the Go type used for values of the property.
+func (s *Schema) GoType(p *Property, names map[*Type]string) string {
+	switch p.Type {
+	case "ArrayProperty":
+		switch p.ElementType {
+		// array items are read without the enum unwrapped
+		case "EnumProperty":
+			return "[]remnant.EnumProperty"
+		case "ByteProperty":
+			return "[]uint8"
+		}
+		return "[]" + s.goValueType(p.ElementType, p.StructName, names)
+
+	case "MapProperty":
+		return "[]remnant.MapPropertyValue"
+
+	case "ByteProperty":
+		// byte properties with values are enums
+		if len(p.Values) > 0 {
+			return "string"
+		}
+	}
+
+	return s.goValueType(p.Type, p.StructName, names)
+}
+
+// FieldNames returns unique Go field names for the properties of a type.
+func FieldNames(t *Type) map[*Property]string {
+	names := map[*Property]string{}
+	used := map[string]int{}
+	for _, p := range t.Properties {
+		name := identifier(p.Name)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s%d", name, used[name])
+		}
+		names[p] = name
+	}
+	return names
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func times(count int) string {
+	if count == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", count)
+}
+
+func propertyComment(t *Type, p *Property) string {
+	var sb strings.Builder
+	sb.WriteString(p.Type)
+	switch {
+	case p.StructName != "" && p.ElementType != "":
+		fmt.Fprintf(&sb, " of %s", p.StructName)
+	case p.ElementType != "":
+		fmt.Fprintf(&sb, " of %s", p.ElementType)
+	case p.KeyType != "":
+		fmt.Fprintf(&sb, " of %s to %s", p.KeyType, p.ValueType)
+	case p.StructName != "":
+		fmt.Fprintf(&sb, " %s", p.StructName)
+	}
+	if p.Min != nil {
+		fmt.Fprintf(&sb, ", %s to %s", formatNumber(*p.Min), formatNumber(*p.Max))
+	}
+	if len(p.Values) > 0 && len(p.Values) <= 8 {
+		fmt.Fprintf(&sb, ", one of %s", strings.Join(p.Values, ", "))
+	}
+	if p.Count < t.Count {
+		fmt.Fprintf(&sb, ", in %d of %d instances", p.Count, t.Count)
+	}
+	return sb.String()
+}
+
+var packageReferences = []struct {
+	pattern *regexp.Regexp
+	path    string
+}{
+	{regexp.MustCompile(`\bfmt\.`), `"fmt"`},
+	{regexp.MustCompile(`\bremnant\.`), `"revision-go/remnant"`},
+	{regexp.MustCompile(`\btyped\.`), `"revision-go/remnant/typed"`},
+	{regexp.MustCompile(`\bue\.`), `"revision-go/ue"`},
+}
+
+// writeGoFile formats the generated declarations and writes them with a
+// header and the imports they use.
+func writeGoFile(w io.Writer, header string, pkg string, body []byte) error {
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "%s\n\npackage %s\n\n", header, pkg)
+
+	imports := []string{}
+	for _, reference := range packageReferences {
+		if reference.pattern.Match(body) {
+			imports = append(imports, reference.path)
+		}
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&file, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	file.Write(body)
+
+	source, err := format.Source(file.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated invalid Go code: %w", err)
+	}
+
+	_, err = w.Write(source)
+	return err
+}
+
+// WriteGoStubs writes a Go struct declaration for every type of the schema.
+func (s *Schema) WriteGoStubs(w io.Writer, pkg string) error {
+	names := s.TypeNames()
+
+	var body bytes.Buffer
+	for _, t := range s.Types {
+		// objects without properties are mostly items referenced by path
+		if len(t.Properties) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&body, "// %s is the %s %s, seen %s.\n", names[t], t.Kind, t.Name, times(t.Count))
+		fmt.Fprintf(&body, "type %s struct {\n", names[t])
+		fields := FieldNames(t)
+		for _, p := range t.Properties {
+			fmt.Fprintf(&body, "%s %s // %s\n", fields[p], s.GoType(p, names), propertyComment(t, p))
+		}
+		fmt.Fprint(&body, "}\n\n")
+	}
+
+	return writeGoFile(w, fmt.Sprintf("// Generated by revision schema from %d saves.", s.Saves), pkg, body.Bytes())
+}
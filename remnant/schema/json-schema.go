@@ -0,0 +1,171 @@
+package schema
+
+import "fmt"
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// leafStructSchemas are structs that are read as a single value rather than
+// as properties.
+var leafStructSchemas = map[string]map[string]interface{}{
+	"Vector": {
+		"type": "object",
+		"properties": map[string]interface{}{
+			"X": map[string]interface{}{"type": "number"},
+			"Y": map[string]interface{}{"type": "number"},
+			"Z": map[string]interface{}{"type": "number"},
+		},
+	},
+	"Guid": {
+		"type": "object",
+		"properties": map[string]interface{}{
+			"A": map[string]interface{}{"type": "integer"},
+			"B": map[string]interface{}{"type": "integer"},
+			"C": map[string]interface{}{"type": "integer"},
+			"D": map[string]interface{}{"type": "integer"},
+		},
+	},
+	"DateTime":        {"type": "integer", "description": "ticks of 100ns since 0001-01-01"},
+	"Timespan":        {"type": "integer", "description": "ticks of 100ns"},
+	"SoftClassPath":   {"type": "string"},
+	"SoftObjectPath":  {"type": "string"},
+	"PersistenceBlob": {"type": "object"},
+}
+
+func (s *Schema) valueSchema(valueType string, structName string, names map[*Type]string) map[string]interface{} {
+	switch valueType {
+	case "IntProperty", "Int16Property", "Int64Property", "UInt16Property", "UInt32Property", "UInt64Property", "ByteProperty":
+		return map[string]interface{}{"type": "integer"}
+
+	case "FloatProperty", "DoubleProperty", "FloatProeprty":
+		return map[string]interface{}{"type": "number"}
+
+	case "BoolProperty":
+		return map[string]interface{}{"type": "boolean"}
+
+	case "StrProperty", "NameProperty", "SoftObjectProperty", "SoftClassPath":
+		return map[string]interface{}{"type": "string"}
+
+	case "EnumProperty":
+		return map[string]interface{}{"type": "string"}
+
+	case "ObjectProperty":
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"ObjectID":  map[string]interface{}{"type": "integer"},
+				"ClassName": map[string]interface{}{"type": "string"},
+			},
+		}
+
+	case "TextProperty":
+		return map[string]interface{}{"type": "object"}
+
+	case "StructProperty":
+		if leaf, ok := leafStructSchemas[structName]; ok {
+			result := map[string]interface{}{}
+			for key, value := range leaf {
+				result[key] = value
+			}
+			return result
+		}
+	}
+
+	// structs and the variables of variables components
+	if t := s.Find(KindStruct, structName); t != nil {
+		return map[string]interface{}{"$ref": "#/$defs/" + names[t]}
+	}
+
+	return map[string]interface{}{}
+}
+
+func (s *Schema) propertySchema(t *Type, p *Property, names map[*Type]string) map[string]interface{} {
+	var result map[string]interface{}
+
+	switch p.Type {
+	case "ArrayProperty":
+		result = map[string]interface{}{
+			"type":  "array",
+			"items": s.valueSchema(p.ElementType, p.StructName, names),
+		}
+		if p.MinLength != nil {
+			result["minItems"] = *p.MinLength
+			result["maxItems"] = *p.MaxLength
+		}
+
+	case "MapProperty":
+		result = map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"Key":   s.valueSchema(p.KeyType, "", names),
+					"Value": s.valueSchema(p.ValueType, "", names),
+				},
+			},
+		}
+		if p.MinLength != nil {
+			result["minItems"] = *p.MinLength
+			result["maxItems"] = *p.MaxLength
+		}
+
+	default:
+		result = s.valueSchema(p.Type, p.StructName, names)
+		if p.Min != nil {
+			result["minimum"] = *p.Min
+			result["maximum"] = *p.Max
+		}
+		if p.MinLength != nil {
+			result["minLength"] = *p.MinLength
+			result["maxLength"] = *p.MaxLength
+		}
+		if len(p.Values) > 0 {
+			// byte properties with values are enums
+			result["type"] = "string"
+			result["enum"] = p.Values
+		}
+	}
+
+	result["x-property-type"] = p.Type
+	if len(p.OtherTypes) > 0 {
+		result["x-other-property-types"] = p.OtherTypes
+	}
+	result["description"] = fmt.Sprintf("%s, in %d of %d instances", p.Type, p.Count, t.Count)
+
+	return result
+}
+
+// JSONSchema returns a JSON Schema document with a definition for every type.
+// A definition describes an instance as an object keyed by property name, with
+// enums as their value and structs as references to their definitions;
+// properties seen in every instance are required.
+func (s *Schema) JSONSchema() map[string]interface{} {
+	names := s.TypeNames()
+
+	definitions := map[string]interface{}{}
+	for _, t := range s.Types {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, p := range t.Properties {
+			properties[p.Name] = s.propertySchema(t, p, names)
+			if p.Count == t.Count {
+				required = append(required, p.Name)
+			}
+		}
+
+		definitions[names[t]] = map[string]interface{}{
+			"title":       t.Name,
+			"description": fmt.Sprintf("%s %s, seen %s", t.Kind, t.Name, times(t.Count)),
+			"type":        "object",
+			"properties":  properties,
+			"required":    required,
+			"x-kind":      t.Kind,
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDialect,
+		"title":       "Remnant 2 save types",
+		"description": fmt.Sprintf("inferred from %d saves", s.Saves),
+		"$defs":       definitions,
+	}
+}
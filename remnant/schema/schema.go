@@ -0,0 +1,385 @@
+// Package schema describes the property layout of objects, components and
+// structs, inferred from parsed saves or written by hand, and turns it into
+// JSON Schema and Go code.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"revision-go/remnant"
+	"sort"
+	"strings"
+)
+
+const (
+	KindObject    = "object"
+	KindComponent = "component"
+	KindStruct    = "struct"
+)
+
+// values of enum and name properties are listed up to this many distinct
+// values, more are taken to be free-form
+const maxValues = 32
+
+type Property struct {
+	Name string
+	// most common property type, other observed types are in OtherTypes
+	Type       string
+	OtherTypes []string
+	// struct name of struct properties and arrays of structs
+	StructName string
+	// element type of arrays
+	ElementType string
+	// key and value types of maps
+	KeyType   string
+	ValueType string
+	// number of instances of the owning type that have the property and
+	// their share of all instances
+	Count     int
+	Frequency float64
+	// range of numeric values
+	Min *float64
+	Max *float64
+	// range of the number of array and map items and of string lengths
+	MinLength *int
+	MaxLength *int
+	// observed enum and name values, if there are few of them
+	Values []string
+
+	types  map[string]int
+	values map[string]bool
+}
+
+// Type is an object class, component or struct with properties.
+type Type struct {
+	Kind string
+	// class path for objects, component key for components and struct name
+	// for structs
+	Name string
+	// number of instances seen
+	Count      int
+	Properties []*Property
+
+	properties map[string]*Property
+}
+
+type Schema struct {
+	// number of saves the schema was inferred from
+	Saves int
+	Types []*Type
+
+	types map[string]*Type
+}
+
+func New() *Schema {
+	return &Schema{types: map[string]*Type{}}
+}
+
+// Read decodes a schema written by Write or by hand.
+func Read(r io.Reader) (*Schema, error) {
+	s := New()
+	err := json.NewDecoder(r).Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range s.Types {
+		s.types[t.Kind+"\x00"+t.Name] = t
+		t.properties = map[string]*Property{}
+		for _, property := range t.Properties {
+			property.types = map[string]int{property.Type: property.Count}
+			property.values = map[string]bool{}
+			t.properties[property.Name] = property
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Schema) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Find returns the type of the given kind and name, or nil.
+func (s *Schema) Find(kind string, name string) *Type {
+	return s.types[kind+"\x00"+name]
+}
+
+func (s *Schema) typeOf(kind string, name string) *Type {
+	t := s.Find(kind, name)
+	if t == nil {
+		t = &Type{Kind: kind, Name: name, properties: map[string]*Property{}}
+		s.types[kind+"\x00"+name] = t
+		s.Types = append(s.Types, t)
+	}
+	return t
+}
+
+func (t *Type) property(name string) *Property {
+	property, ok := t.properties[name]
+	if !ok {
+		property = &Property{Name: name, types: map[string]int{}, values: map[string]bool{}}
+		t.properties[name] = property
+		t.Properties = append(t.Properties, property)
+	}
+	return property
+}
+
+// levelInstance matches the numbered name of an actor placed in a level, such
+// as :PersistentLevel.ZoneActor_12
+var levelInstance = regexp.MustCompile(`(:[^/]*\.[^./]*?)_\d+$`)
+
+// ClassPath returns the object path with the instance number of level actors
+// removed, so that instances of the same class share a type.
+func ClassPath(objectPath string) string {
+	return levelInstance.ReplaceAllString(objectPath, "$1")
+}
+
+// Add infers the types of the objects, components and structs of a save,
+// including those of the archives nested in it.
+func (s *Schema) Add(archive *remnant.SaveArchive) {
+	s.Saves++
+	s.addSaveData(&archive.Data)
+	s.finish()
+}
+
+func (s *Schema) addSaveData(data *remnant.SaveData) {
+	for i := range data.Objects {
+		object := &data.Objects[i]
+		s.addProperties(s.typeOf(KindObject, ClassPath(object.ObjectPath)), object.Properties)
+
+		for j := range object.Components {
+			component := &object.Components[j]
+			s.addProperties(s.typeOf(KindComponent, component.ComponentKey), component.Properties)
+		}
+	}
+}
+
+func (s *Schema) addProperties(t *Type, properties []remnant.Property) {
+	t.Count++
+
+	seen := map[string]bool{}
+	for i := range properties {
+		property := t.property(properties[i].Name)
+		if !seen[property.Name] {
+			seen[property.Name] = true
+			property.Count++
+		}
+		property.types[properties[i].Type]++
+		s.addValue(property, properties[i].Value)
+	}
+}
+
+func (p *Property) addRange(value float64) {
+	if p.Min == nil || value < *p.Min {
+		p.Min = &value
+	}
+	if p.Max == nil || value > *p.Max {
+		p.Max = &value
+	}
+}
+
+func (p *Property) addLength(length int) {
+	if p.MinLength == nil || length < *p.MinLength {
+		p.MinLength = &length
+	}
+	if p.MaxLength == nil || length > *p.MaxLength {
+		p.MaxLength = &length
+	}
+}
+
+func (p *Property) addName(value string) {
+	if len(p.values) <= maxValues {
+		p.values[value] = true
+	}
+}
+
+// addScalar records the range of a value that has no properties of its own.
+func (p *Property) addScalar(value interface{}) {
+	switch v := value.(type) {
+	case int16:
+		p.addRange(float64(v))
+	case int32:
+		p.addRange(float64(v))
+	case int64:
+		p.addRange(float64(v))
+	case uint8:
+		p.addRange(float64(v))
+	case uint16:
+		p.addRange(float64(v))
+	case uint32:
+		p.addRange(float64(v))
+	case uint64:
+		p.addRange(float64(v))
+	case float32:
+		p.addRange(float64(v))
+	case float64:
+		p.addRange(v)
+	case string:
+		p.addLength(len(v))
+	case remnant.EnumProperty:
+		p.addName(v.EnumValue)
+	}
+}
+
+func (s *Schema) addValue(p *Property, value interface{}) {
+	switch v := value.(type) {
+	case remnant.StructProperty:
+		p.StructName = v.Name
+		s.addStruct(v)
+
+	case remnant.ArrayStructProperty:
+		p.ElementType = "StructProperty"
+		p.StructName = v.ElementType
+		p.addLength(len(v.Items))
+		for _, item := range v.Items {
+			s.addStruct(item)
+		}
+
+	case remnant.ArrayProperty:
+		p.ElementType = v.ElementType
+		p.addLength(len(v.Items))
+		for _, item := range v.Items {
+			p.addScalar(item)
+		}
+
+	case remnant.MapProperty:
+		p.KeyType = v.KeyType
+		p.ValueType = v.ValueType
+		p.addLength(len(v.Values))
+
+	case remnant.Variables:
+		p.StructName = v.Name
+		s.addProperties(s.typeOf(KindStruct, v.Name), v.Properties)
+
+	default:
+		if p.types["NameProperty"] > 0 {
+			if name, ok := value.(string); ok {
+				p.addName(name)
+				return
+			}
+		}
+		p.addScalar(value)
+	}
+}
+
+func (s *Schema) addStruct(value remnant.StructProperty) {
+	switch v := value.Value.(type) {
+	case []remnant.Property:
+		s.addProperties(s.typeOf(KindStruct, value.Name), v)
+
+	case remnant.PersistenceBlob:
+		s.addSaveData(&v.Archive)
+
+	case remnant.PersistenceContainer:
+		for _, id := range sortedActorIDs(v.Actors) {
+			actor := v.Actors[id]
+			s.addSaveData(&actor.Archive)
+		}
+	}
+}
+
+func sortedActorIDs(actors map[uint64]remnant.Actor) []uint64 {
+	ids := make([]uint64, 0, len(actors))
+	for id := range actors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// finish fills in the fields derived from the observations and sorts the
+// types and properties by name.
+func (s *Schema) finish() {
+	sort.Slice(s.Types, func(i, j int) bool {
+		if s.Types[i].Kind != s.Types[j].Kind {
+			return s.Types[i].Kind < s.Types[j].Kind
+		}
+		return s.Types[i].Name < s.Types[j].Name
+	})
+
+	for _, t := range s.Types {
+		sort.Slice(t.Properties, func(i, j int) bool {
+			return t.Properties[i].Name < t.Properties[j].Name
+		})
+
+		for _, property := range t.Properties {
+			property.Frequency = float64(property.Count) / float64(t.Count)
+
+			types := make([]string, 0, len(property.types))
+			for name := range property.types {
+				types = append(types, name)
+			}
+			sort.Slice(types, func(i, j int) bool {
+				if property.types[types[i]] != property.types[types[j]] {
+					return property.types[types[i]] > property.types[types[j]]
+				}
+				return types[i] < types[j]
+			})
+			if len(types) > 0 {
+				property.Type = types[0]
+				property.OtherTypes = types[1:]
+			}
+
+			property.Values = nil
+			if len(property.values) > 0 && len(property.values) <= maxValues {
+				for value := range property.values {
+					property.Values = append(property.Values, value)
+				}
+				sort.Strings(property.Values)
+			}
+		}
+	}
+}
+
+// identifier turns a name into an exported Go identifier.
+func identifier(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	result := strings.Trim(sb.String(), "_")
+	if result == "" || result[0] >= '0' && result[0] <= '9' {
+		result = "T" + result
+	}
+	return strings.ToUpper(result[:1]) + result[1:]
+}
+
+// TypeNames returns unique Go identifiers for the types of the schema, keyed
+// by type.
+func (s *Schema) TypeNames() map[*Type]string {
+	names := map[*Type]string{}
+	used := map[string]int{}
+
+	for _, t := range s.Types {
+		name := t.Name
+		if t.Kind == KindObject {
+			// the asset name: the last segment of the path, after the level
+			if i := strings.LastIndexAny(name, "/:."); i >= 0 {
+				name = name[i+1:]
+			}
+		}
+		name = identifier(name)
+		if t.Kind == KindComponent {
+			name += "Component"
+		}
+
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s%d", name, used[name])
+		}
+		names[t] = name
+	}
+
+	return names
+}
@@ -0,0 +1,160 @@
+package remnant
+
+import (
+	"sort"
+	"strings"
+)
+
+type TypeCount struct {
+	Type  string
+	Count int
+}
+
+type PropertySize struct {
+	Path Path
+	Type string
+	Size uint32
+}
+
+// DuplicateName is a name that occurs more than once in the names table of
+// the archive at Path.
+type DuplicateName struct {
+	Path  Path
+	Name  string
+	Count int
+}
+
+// ContainerStats counts the actors of the PersistenceContainer at Path.
+type ContainerStats struct {
+	Path          Path
+	Actors        int
+	DynamicActors int
+	Destroyed     int
+}
+
+// Stats summarizes the contents of a save. Objects, components and
+// properties are counted in the save and in the archives of all actors.
+type Stats struct {
+	Archives   int
+	Objects    int
+	Components int
+	Properties int
+	// number of properties of every type, most common first
+	PropertyTypes []TypeCount
+	// properties with the largest Size, largest first
+	LargestProperties []PropertySize
+	// number of nodes containing the deepest node, see Walk
+	MaxDepth    int
+	DeepestPath Path
+	// size of the names table of the save
+	Names          int
+	DuplicateNames []DuplicateName
+	Containers     []ContainerStats
+	Actors         int
+	DynamicActors  int
+	Destroyed      int
+}
+
+func (s *Stats) addContainer(path Path, value interface{}) {
+	structValue, ok := value.(StructProperty)
+	if !ok {
+		return
+	}
+	container, ok := structValue.Value.(PersistenceContainer)
+	if !ok {
+		return
+	}
+
+	stats := ContainerStats{
+		Path:      path,
+		Actors:    len(container.Actors),
+		Destroyed: len(container.Destroyed),
+	}
+	for _, actor := range container.Actors {
+		if actor.DynamicData != nil {
+			stats.DynamicActors++
+		}
+	}
+
+	s.Containers = append(s.Containers, stats)
+	s.Actors += stats.Actors
+	s.DynamicActors += stats.DynamicActors
+	s.Destroyed += stats.Destroyed
+}
+
+func (s *Stats) addNames(path Path, names []string) {
+	counts := map[string]int{}
+	for _, name := range names {
+		counts[name]++
+	}
+	// in table order
+	for _, name := range names {
+		if counts[name] > 1 {
+			s.DuplicateNames = append(s.DuplicateNames, DuplicateName{Path: path, Name: name, Count: counts[name]})
+			counts[name] = 0
+		}
+	}
+}
+
+// NewStats returns the statistics of a save, keeping the top largest
+// properties, all of them if top is 0.
+func NewStats(archive *SaveArchive, top int) *Stats {
+	s := &Stats{
+		PropertyTypes:     []TypeCount{},
+		LargestProperties: []PropertySize{},
+		DuplicateNames:    []DuplicateName{},
+		Containers:        []ContainerStats{},
+		Names:             len(archive.Data.NamesTable),
+	}
+	propertyTypes := map[string]int{}
+
+	// paths of the nodes containing the current one, innermost last
+	parents := []string{}
+
+	Walk(archive, func(path Path, node Node) error {
+		key := path.String()
+		for len(parents) > 0 && !strings.HasPrefix(key, parents[len(parents)-1]+"/") {
+			parents = parents[:len(parents)-1]
+		}
+		if len(parents) > s.MaxDepth {
+			s.MaxDepth = len(parents)
+			s.DeepestPath = path
+		}
+		parents = append(parents, key)
+
+		switch n := node.(type) {
+		case *SaveData:
+			s.Archives++
+			s.addNames(path, n.NamesTable)
+		case *UObject:
+			s.Objects++
+		case *Component:
+			s.Components++
+		case *Property:
+			s.Properties++
+			propertyTypes[n.Type]++
+			s.LargestProperties = append(s.LargestProperties, PropertySize{Path: path, Type: n.Type, Size: n.Size})
+			s.addContainer(path, n.Value)
+		case *StructProperty:
+			s.addContainer(path, *n)
+		}
+		return nil
+	})
+
+	for propertyType, count := range propertyTypes {
+		s.PropertyTypes = append(s.PropertyTypes, TypeCount{Type: propertyType, Count: count})
+	}
+	sort.Slice(s.PropertyTypes, func(i, j int) bool {
+		a, b := s.PropertyTypes[i], s.PropertyTypes[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Type < b.Type
+	})
+
+	sort.SliceStable(s.LargestProperties, func(i, j int) bool {
+		return s.LargestProperties[i].Size > s.LargestProperties[j].Size
+	})
+	if top > 0 && len(s.LargestProperties) > top {
+		s.LargestProperties = s.LargestProperties[:top]
+	}
+
+	return s
+}
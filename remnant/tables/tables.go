@@ -0,0 +1,246 @@
+// Package tables flattens parsed saves into relational tables that can be
+// written as SQL scripts or CSV files and loaded into SQLite. Every row is
+// keyed by the save it comes from and its path, see remnant.Path; parent_path
+// columns hold the path of the row that contains it.
+package tables
+
+import (
+	"encoding/json"
+	"revision-go/remnant"
+	"strconv"
+	"strings"
+)
+
+type Column struct {
+	Name string
+	// SQLite type, empty for columns that hold values of any type
+	Type string
+}
+
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+func newTable(name string, columns ...Column) *Table {
+	keys := []Column{{"save", "TEXT"}, {"path", "TEXT"}, {"parent_path", "TEXT"}}
+	return &Table{Name: name, Columns: append(keys, columns...)}
+}
+
+type Tables struct {
+	Objects    *Table
+	Components *Table
+	Properties *Table
+	ArrayItems *Table
+	MapEntries *Table
+	Actors     *Table
+}
+
+func New() *Tables {
+	return &Tables{
+		Objects: newTable("objects",
+			Column{"object_id", "INTEGER"},
+			Column{"object_path", "TEXT"},
+			Column{"name", "TEXT"},
+			Column{"outer_id", "INTEGER"},
+			Column{"was_loaded", "INTEGER"},
+			Column{"is_actor", "INTEGER"},
+			Column{"unread_bytes", "INTEGER"},
+		),
+		Components: newTable("components",
+			Column{"component_key", "TEXT"},
+			Column{"unread_bytes", "INTEGER"},
+		),
+		Properties: newTable("properties",
+			Column{"name", "TEXT"},
+			Column{"property_index", "INTEGER"},
+			Column{"type", "TEXT"},
+			Column{"struct_name", "TEXT"},
+			Column{"element_type", "TEXT"},
+			Column{"key_type", "TEXT"},
+			Column{"value_type", "TEXT"},
+			Column{"value", ""},
+		),
+		ArrayItems: newTable("array_items",
+			Column{"position", "INTEGER"},
+			Column{"value", ""},
+		),
+		MapEntries: newTable("map_entries",
+			Column{"position", "INTEGER"},
+			Column{"key", ""},
+			Column{"value", ""},
+		),
+		Actors: newTable("actors",
+			Column{"unique_id", "INTEGER"},
+			Column{"class_path", "TEXT"},
+			Column{"dynamic", "INTEGER"},
+			Column{"x", "REAL"},
+			Column{"y", "REAL"},
+			Column{"z", "REAL"},
+		),
+	}
+}
+
+// List returns the tables in the order they are written.
+func (t *Tables) List() []*Table {
+	return []*Table{t.Objects, t.Components, t.Properties, t.ArrayItems, t.MapEntries, t.Actors}
+}
+
+func boolValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// scalar returns the value stored in value columns: numbers, booleans as 0
+// and 1, strings, enum values and the IDs of referenced objects. Vectors,
+// GUIDs and other single value structs are stored as JSON text, lists of
+// properties, arrays and maps as NULL, their contents having rows of their
+// own.
+func scalar(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, []remnant.Property, []byte, remnant.ArrayProperty, remnant.ArrayStructProperty,
+		remnant.MapProperty, remnant.Variables, remnant.PersistenceBlob, remnant.PersistenceContainer:
+		return nil
+
+	case bool:
+		return boolValue(v)
+
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64, float32, float64, string:
+		return v
+
+	case remnant.EnumProperty:
+		return v.EnumValue
+
+	case remnant.ObjectProperty:
+		return v.ObjectID
+
+	case remnant.TextProperty:
+		switch data := v.Data.(type) {
+		case remnant.TextPropertyData:
+			return data.SourceString
+		case remnant.TextData:
+			return data.Data
+		}
+		return nil
+
+	case remnant.StructProperty:
+		return scalar(v.Value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// position returns the last segment of the path of an array item or map
+// entry as a number.
+func position(path remnant.Path) int {
+	i, _ := strconv.Atoi(path[len(path)-1])
+	return i
+}
+
+// Add adds the rows of an archive. save identifies it, e.g. its file name.
+func (t *Tables) Add(save string, archive *remnant.SaveArchive) error {
+	// paths of the rows that contain the current node, innermost last
+	parents := []string{}
+
+	return remnant.Walk(archive, func(path remnant.Path, node remnant.Node) error {
+		key := path.String()
+		for len(parents) > 0 && !strings.HasPrefix(key, parents[len(parents)-1]+"/") {
+			parents = parents[:len(parents)-1]
+		}
+		var parent interface{}
+		if len(parents) > 0 {
+			parent = parents[len(parents)-1]
+		}
+
+		var table *Table
+		var columns []interface{}
+
+		switch n := node.(type) {
+		case *remnant.UObject:
+			var name, outerID interface{}
+			if n.LoadedData != nil {
+				name = n.LoadedData.Name
+				outerID = n.LoadedData.OuterID
+			}
+			table = t.Objects
+			columns = []interface{}{
+				n.ObjectID, n.ObjectPath, name, outerID, boolValue(n.WasLoaded),
+				boolValue(n.Components != nil), len(n.UnreadData),
+			}
+
+		case *remnant.Component:
+			table = t.Components
+			columns = []interface{}{n.ComponentKey, len(n.UnreadData)}
+
+		case *remnant.Property:
+			var structName, elementType, keyType, valueType string
+			switch v := n.Value.(type) {
+			case remnant.StructProperty:
+				structName = v.Name
+			case remnant.Variables:
+				structName = v.Name
+			case remnant.ArrayStructProperty:
+				structName = v.ElementType
+				elementType = "StructProperty"
+			case remnant.ArrayProperty:
+				elementType = v.ElementType
+			case remnant.MapProperty:
+				keyType = v.KeyType
+				valueType = v.ValueType
+			}
+			table = t.Properties
+			columns = []interface{}{
+				n.Name, n.Index, n.Type, nullable(structName), nullable(elementType),
+				nullable(keyType), nullable(valueType), scalar(n.Value),
+			}
+
+		case *remnant.StructProperty:
+			table = t.ArrayItems
+			columns = []interface{}{position(path), scalar(n.Value)}
+
+		case *interface{}:
+			table = t.ArrayItems
+			columns = []interface{}{position(path), scalar(*n)}
+
+		case *remnant.MapPropertyValue:
+			table = t.MapEntries
+			columns = []interface{}{position(path), scalar(n.Key), scalar(n.Value)}
+
+		case *remnant.Actor:
+			uniqueID, _ := strconv.ParseUint(path[len(path)-1], 10, 64)
+			var classPath interface{}
+			if len(n.Archive.Objects) > 0 {
+				classPath = n.Archive.Objects[0].ObjectPath
+			} else if n.DynamicData != nil {
+				classPath = n.DynamicData.ClassPath.Path + "." + n.DynamicData.ClassPath.Name
+			}
+			var x, y, z interface{}
+			if n.Transform != nil {
+				x, y, z = n.Transform.Position.X, n.Transform.Position.Y, n.Transform.Position.Z
+			}
+			table = t.Actors
+			columns = []interface{}{uniqueID, classPath, boolValue(n.DynamicData != nil), x, y, z}
+
+		default:
+			return nil
+		}
+
+		table.Rows = append(table.Rows, append([]interface{}{save, key, parent}, columns...))
+		parents = append(parents, key)
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package tables
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func formatFloat(value float64, bitSize int) string {
+	return strconv.FormatFloat(value, 'g', -1, bitSize)
+}
+
+// sqlLiteral formats a value as an SQLite literal. SQLite integers are
+// signed, so uint64 values above math.MaxInt64 are written as the int64 with
+// the same bits rather than being rounded to a REAL.
+func sqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case uint64:
+		return strconv.FormatInt(int64(v), 10)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case float32:
+		return sqlLiteral(float64(v))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NULL"
+		case math.IsInf(v, 1):
+			return "9e999"
+		case math.IsInf(v, -1):
+			return "-9e999"
+		}
+		return formatFloat(v, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// WriteSQL writes a script that creates the tables if they do not exist and
+// inserts their rows in a single transaction.
+func (t *Tables) WriteSQL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "BEGIN TRANSACTION;")
+	for _, table := range t.List() {
+		columns := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			columns[i] = strings.TrimSpace(column.Name + " " + column.Type)
+		}
+		fmt.Fprintf(bw, "CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (save, path));\n",
+			table.Name, strings.Join(columns, ", "))
+	}
+
+	for _, table := range t.List() {
+		for _, row := range table.Rows {
+			values := make([]string, len(row))
+			for i, value := range row {
+				values[i] = sqlLiteral(value)
+			}
+			fmt.Fprintf(bw, "INSERT INTO %s VALUES (%s);\n", table.Name, strings.Join(values, ", "))
+		}
+	}
+	fmt.Fprintln(bw, "COMMIT;")
+
+	return bw.Flush()
+}
+
+func csvField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// WriteCSV writes a table with a header row. NULL is written as an empty
+// field.
+func (table *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	cw.Write(header)
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			record[i] = csvField(value)
+		}
+		cw.Write(record)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,165 @@
+package remnant
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// TraceEntry describes a range of the decompressed save data that the parser
+// read as a single field.
+type TraceEntry struct {
+	Offset int64
+	Length int64
+	// path of the node the field belongs to, as used by Lookup
+	Path  Path
+	Field string
+	// decoded value of the field; nil for containers, whose contents have
+	// entries of their own
+	Value interface{}
+	// set for data left over by readObjectData and readComponents
+	Unread bool
+}
+
+// Trace is the list of fields read by TraceSaveArchive, in the order they were
+// read.
+type Trace struct {
+	Entries []TraceEntry
+
+	path Path
+	// index of the first entry recorded under each segment of path
+	scopes []int
+}
+
+// traceReader records a TraceEntry for every traced field. Fields are traced
+// after they have been read and cover everything read since the previous
+// field, so padding that is skipped before a field is part of it.
+type traceReader struct {
+	io.ReadSeeker
+	trace *Trace
+	// offset of the reader's data in the decompressed save
+	base int64
+	// start of the data not covered by an entry yet
+	mark int64
+}
+
+func (r *traceReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.ReadSeeker.Seek(offset, whence)
+	if err == nil && whence != io.SeekCurrent {
+		r.mark = pos
+	}
+	return pos, err
+}
+
+func (r *traceReader) pos() int64 {
+	pos, err := r.ReadSeeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return r.mark
+	}
+	return pos
+}
+
+func (r *traceReader) record(field string, value interface{}, unread bool) {
+	pos := r.pos()
+	if pos <= r.mark {
+		return
+	}
+
+	switch value.(type) {
+	case []Property, StructProperty, ArrayProperty, ArrayStructProperty, MapProperty,
+		PersistenceBlob, PersistenceContainer, Variables, SaveData:
+		value = nil
+	}
+
+	r.trace.Entries = append(r.trace.Entries, TraceEntry{
+		Offset: r.base + r.mark,
+		Length: pos - r.mark,
+		Path:   r.trace.path.Append(),
+		Field:  field,
+		Value:  value,
+		Unread: unread,
+	})
+	r.mark = pos
+}
+
+// traceField records the data read from r since the last traced field, if r
+// is being traced.
+func traceField(r io.Reader, field string, value interface{}) {
+	if t, ok := r.(*traceReader); ok {
+		t.record(field, value, false)
+	}
+}
+
+func traceUnread(r io.Reader, data []byte) {
+	if t, ok := r.(*traceReader); ok {
+		t.record("UnreadData", data, true)
+	}
+}
+
+// tracePush appends segments to the path of the fields traced from r until
+// the returned function is called.
+func tracePush(r io.Reader, segments ...string) func() {
+	t, ok := r.(*traceReader)
+	if !ok {
+		return func() {}
+	}
+
+	trace := t.trace
+	for range segments {
+		trace.scopes = append(trace.scopes, len(trace.Entries))
+	}
+	trace.path = trace.path.Append(segments...)
+
+	return func() {
+		trace.path = trace.path[:len(trace.path)-len(segments)]
+		trace.scopes = trace.scopes[:len(trace.scopes)-len(segments)]
+	}
+}
+
+// traceRename replaces the last path segment, including in the entries that
+// were already traced under it.
+func traceRename(r io.Reader, segment string) {
+	t, ok := r.(*traceReader)
+	if !ok {
+		return
+	}
+
+	trace := t.trace
+	depth := len(trace.path) - 1
+	trace.path[depth] = segment
+	for i := trace.scopes[depth]; i < len(trace.Entries); i++ {
+		trace.Entries[i].Path[depth] = segment
+	}
+}
+
+// subReader returns a reader for data that has just been read from r, traced
+// at its position in r if r is traced.
+func subReader(r io.Reader, data []byte) io.ReadSeeker {
+	t, ok := r.(*traceReader)
+	if !ok {
+		return bytes.NewReader(data)
+	}
+
+	pos := t.pos()
+	t.mark = pos
+	return &traceReader{
+		ReadSeeker: bytes.NewReader(data),
+		trace:      t.trace,
+		base:       t.base + pos - int64(len(data)),
+	}
+}
+
+// TraceSaveArchive is ReadSaveArchive that also returns every field read, for
+// looking at the binary layout of a save. The trace is returned even if
+// parsing fails, covering the fields read up to the failure.
+func TraceSaveArchive(r io.ReadSeeker) (SaveArchive, *Trace, error) {
+	trace := &Trace{}
+
+	archive, err := ReadSaveArchive(&traceReader{ReadSeeker: r, trace: trace})
+
+	sort.SliceStable(trace.Entries, func(i, j int) bool {
+		return trace.Entries[i].Offset < trace.Entries[j].Offset
+	})
+
+	return archive, trace, err
+}
@@ -0,0 +1,245 @@
+// Package typed converts between property lists and the typed structs
+// generated by revision codegen. Getters return an error naming the expected
+// type if a property does not hold it.
+package typed
+
+import (
+	"fmt"
+	"revision-go/remnant"
+	"strings"
+)
+
+func typeError(property *remnant.Property, expected string, value interface{}) error {
+	return fmt.Errorf("expected %s, got %s holding %T", expected, property.Type, value)
+}
+
+// Value returns the value of a scalar property.
+func Value[T any](property *remnant.Property) (T, error) {
+	value, ok := property.Value.(T)
+	if !ok {
+		return value, typeError(property, fmt.Sprintf("%T", value), property.Value)
+	}
+	return value, nil
+}
+
+// Enum returns the value of an enum property, or of a byte property holding
+// an enum.
+func Enum(property *remnant.Property) (string, error) {
+	value, ok := property.Value.(remnant.EnumProperty)
+	if !ok {
+		return "", typeError(property, "enum", property.Value)
+	}
+	return value.EnumValue, nil
+}
+
+// Struct returns the properties of a struct property or the variables of a
+// variables component.
+func Struct(property *remnant.Property) ([]remnant.Property, error) {
+	switch v := property.Value.(type) {
+	case remnant.StructProperty:
+		properties, ok := v.Value.([]remnant.Property)
+		if !ok {
+			return nil, typeError(property, "struct with properties", v.Value)
+		}
+		return properties, nil
+
+	case remnant.Variables:
+		return v.Properties, nil
+	}
+
+	return nil, typeError(property, "struct", property.Value)
+}
+
+// StructValue returns the value of a struct that is read as a single value,
+// such as a Vector or DateTime.
+func StructValue[T any](property *remnant.Property) (T, error) {
+	var value T
+
+	structProperty, ok := property.Value.(remnant.StructProperty)
+	if !ok {
+		return value, typeError(property, "struct", property.Value)
+	}
+
+	value, ok = structProperty.Value.(T)
+	if !ok {
+		return value, typeError(property, fmt.Sprintf("%T", value), structProperty.Value)
+	}
+	return value, nil
+}
+
+// Items returns the items of an array property.
+func Items[T any](property *remnant.Property) ([]T, error) {
+	array, ok := property.Value.(remnant.ArrayProperty)
+	if !ok {
+		return nil, typeError(property, "array", property.Value)
+	}
+
+	items := make([]T, len(array.Items))
+	for i, item := range array.Items {
+		items[i], ok = item.(T)
+		if !ok {
+			return nil, fmt.Errorf("item %d: expected %T, got %T", i, items[i], item)
+		}
+	}
+	return items, nil
+}
+
+// StructItems returns the values of the items of an array of structs: a
+// property list for structs with properties.
+func StructItems[T any](property *remnant.Property) ([]T, error) {
+	array, ok := property.Value.(remnant.ArrayStructProperty)
+	if !ok {
+		return nil, typeError(property, "array of structs", property.Value)
+	}
+
+	items := make([]T, len(array.Items))
+	for i, item := range array.Items {
+		items[i], ok = item.Value.(T)
+		if !ok {
+			return nil, fmt.Errorf("item %d: expected %T, got %T", i, items[i], item.Value)
+		}
+	}
+	return items, nil
+}
+
+// MapEntries returns the entries of a map property.
+func MapEntries(property *remnant.Property) ([]remnant.MapPropertyValue, error) {
+	value, ok := property.Value.(remnant.MapProperty)
+	if !ok {
+		return nil, typeError(property, "map", property.Value)
+	}
+	return value.Values, nil
+}
+
+// ToItems converts a slice to the items of an array property.
+func ToItems[T any](values []T) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+	return items
+}
+
+// Builder updates a copy of a property list. Properties keep their position
+// and the parts of their values that typed structs do not hold, such as GUIDs
+// and enum types; new properties are appended.
+type Builder struct {
+	properties []remnant.Property
+}
+
+func NewBuilder(properties []remnant.Property) *Builder {
+	return &Builder{properties: append([]remnant.Property{}, properties...)}
+}
+
+func (b *Builder) find(name string) *remnant.Property {
+	return remnant.FindProperty(b.properties, name)
+}
+
+func (b *Builder) set(name string, propertyType string, value interface{}) {
+	if property := b.find(name); property != nil {
+		property.Type = propertyType
+		property.Value = value
+		return
+	}
+
+	b.properties = append(b.properties, remnant.Property{
+		Name:  name,
+		Type:  propertyType,
+		Value: value,
+	})
+}
+
+// Set sets a scalar property.
+func (b *Builder) Set(name string, propertyType string, value interface{}) {
+	b.set(name, propertyType, value)
+}
+
+// SetEnum sets an enum property, or a byte property holding an enum. The enum
+// type of a new property is taken from the value, e.g. EQuestState for
+// EQuestState::Complete.
+func (b *Builder) SetEnum(name string, propertyType string, value string) {
+	enumType := value
+	if i := strings.Index(value, "::"); i >= 0 {
+		enumType = value[:i]
+	}
+	if property := b.find(name); property != nil {
+		if previous, ok := property.Value.(remnant.EnumProperty); ok {
+			enumType = previous.EnumType
+		}
+	}
+
+	b.set(name, propertyType, remnant.EnumProperty{EnumType: enumType, EnumValue: value})
+}
+
+// SetStruct sets a struct property to a property list or to the value of a
+// struct that is read as a single value.
+func (b *Builder) SetStruct(name string, structName string, value interface{}) {
+	structProperty := remnant.StructProperty{Name: structName}
+	if property := b.find(name); property != nil {
+		if previous, ok := property.Value.(remnant.StructProperty); ok {
+			structProperty = previous
+		}
+	}
+	structProperty.Value = value
+
+	b.set(name, "StructProperty", structProperty)
+}
+
+// SetVariables sets the variables of a variables component.
+func (b *Builder) SetVariables(name string, variablesName string, properties []remnant.Property) {
+	b.set(name, name, remnant.Variables{Name: variablesName, Properties: properties})
+}
+
+// SetArray sets an array property.
+func (b *Builder) SetArray(name string, elementType string, items []interface{}) {
+	b.set(name, "ArrayProperty", remnant.ArrayProperty{
+		Count:       uint32(len(items)),
+		Items:       items,
+		ElementType: elementType,
+	})
+}
+
+// SetStructArray sets an array of structs to the given item values.
+func (b *Builder) SetStructArray(name string, structName string, values []interface{}) {
+	array := remnant.ArrayStructProperty{ElementType: structName}
+	if property := b.find(name); property != nil {
+		if previous, ok := property.Value.(remnant.ArrayStructProperty); ok {
+			array = previous
+		}
+	}
+
+	array.Count = uint32(len(values))
+	array.Items = make([]remnant.StructProperty, len(values))
+	for i, value := range values {
+		array.Items[i] = remnant.StructProperty{
+			Name:  array.ElementType,
+			GUID:  array.GUID,
+			Value: value,
+		}
+	}
+
+	b.set(name, "ArrayProperty", array)
+}
+
+// SetMap sets a map property.
+func (b *Builder) SetMap(name string, keyType string, valueType string, entries []remnant.MapPropertyValue) {
+	b.set(name, "MapProperty", remnant.MapProperty{
+		KeyType:   keyType,
+		ValueType: valueType,
+		Values:    entries,
+	})
+}
+
+// Remove removes a property, for optional fields that are not set.
+func (b *Builder) Remove(name string) {
+	for i := range b.properties {
+		if b.properties[i].Name == name {
+			b.properties = append(b.properties[:i], b.properties[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *Builder) Properties() []remnant.Property {
+	return b.properties
+}
@@ -0,0 +1,418 @@
+package remnant
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path addresses a node inside a parsed save. Segments follow the field names
+// of the parsed model: objects are addressed by their ObjectID, components by
+// ComponentKey, properties by Name (or Name[Index] for a non-zero Index),
+// array and map items by position and actors by UniqueID, e.g.
+//
+//	/Objects/0/Components/GlobalVariables/GlobalVariables/SomeFlag
+//	/Objects/0/Properties/SaveData/Actors/42/Archive/Objects/1/Properties/Items/3
+type Path []string
+
+func (p Path) String() string {
+	var sb strings.Builder
+	for _, segment := range p {
+		sb.WriteByte('/')
+		segment = strings.ReplaceAll(segment, "~", "~0")
+		sb.WriteString(strings.ReplaceAll(segment, "/", "~1"))
+	}
+	return sb.String()
+}
+
+func (p Path) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Path) UnmarshalText(text []byte) error {
+	path, err := ParsePath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
+
+// Append returns a copy of the path extended with the given segments.
+func (p Path) Append(segments ...string) Path {
+	result := make(Path, 0, len(p)+len(segments))
+	result = append(result, p...)
+	return append(result, segments...)
+}
+
+func ParsePath(s string) (Path, error) {
+	if s == "" || s == "/" {
+		return Path{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("path must start with '/': %q", s)
+	}
+
+	segments := strings.Split(s[1:], "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(segment, "~1", "/")
+		segments[i] = strings.ReplaceAll(segment, "~0", "~")
+	}
+
+	return Path(segments), nil
+}
+
+// Node is one of *SaveData, *UObject, *Component, *Property, *StructProperty
+// (an element of an array of structs), *interface{} (an element of any other
+// array), *MapPropertyValue or *Actor.
+type Node interface{}
+
+type WalkFunc func(path Path, node Node) error
+
+var (
+	// SkipNode can be returned by a WalkFunc to skip the children of a node.
+	SkipNode = errors.New("skip this node")
+	// StopWalk can be returned by a WalkFunc to end the walk early.
+	StopWalk = errors.New("stop walk")
+	// RemoveNode can be returned by a WalkFunc passed to Mutate to remove the
+	// node from its parent.
+	RemoveNode = errors.New("remove this node")
+)
+
+type edge struct {
+	segments []string
+	node     Node
+	remove   func() error
+}
+
+func propertyKey(property *Property) string {
+	if property.Index != 0 {
+		return fmt.Sprintf("%s[%d]", property.Name, property.Index)
+	}
+	return property.Name
+}
+
+func removeAt[T any](list *[]T, i int) func() error {
+	return func() error {
+		*list = append((*list)[:i], (*list)[i+1:]...)
+		return nil
+	}
+}
+
+func cannotRemove(kind string) func() error {
+	return func() error {
+		return fmt.Errorf("%s cannot be removed", kind)
+	}
+}
+
+func propertyEdges(properties *[]Property) []edge {
+	edges := make([]edge, len(*properties))
+	for i := range *properties {
+		property := &(*properties)[i]
+		edges[i] = edge{
+			segments: []string{propertyKey(property)},
+			node:     property,
+			remove:   removeAt(properties, i),
+		}
+	}
+	return edges
+}
+
+func sortedActorIDs(actors map[uint64]Actor) []uint64 {
+	ids := make([]uint64, 0, len(actors))
+	for id := range actors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// structValueEdges expands the value of a struct. The returned function yields
+// the (possibly modified) value to store back into the parent.
+func structValueEdges(value interface{}) ([]edge, func() interface{}) {
+	switch v := value.(type) {
+	case []Property:
+		edges := propertyEdges(&v)
+		return edges, func() interface{} { return v }
+
+	case PersistenceBlob:
+		edges := []edge{{
+			segments: []string{"Archive"},
+			node:     &v.Archive,
+			remove:   cannotRemove("archive"),
+		}}
+		return edges, func() interface{} { return v }
+
+	case PersistenceContainer:
+		ids := sortedActorIDs(v.Actors)
+		actors := make([]Actor, len(ids))
+		edges := make([]edge, len(ids))
+		removed := make(map[uint64]bool)
+		for i, id := range ids {
+			id := id
+			actors[i] = v.Actors[id]
+			edges[i] = edge{
+				segments: []string{"Actors", strconv.FormatUint(id, 10)},
+				node:     &actors[i],
+				remove: func() error {
+					removed[id] = true
+					return nil
+				},
+			}
+		}
+		return edges, func() interface{} {
+			for i, id := range ids {
+				if removed[id] {
+					delete(v.Actors, id)
+					continue
+				}
+				v.Actors[id] = actors[i]
+			}
+			return v
+		}
+	}
+
+	return nil, func() interface{} { return value }
+}
+
+// expand returns the children of a node and a function that stores changes
+// made to value-typed children back into the node.
+func expand(node Node) ([]edge, func()) {
+	noop := func() {}
+
+	switch n := node.(type) {
+	case *SaveData:
+		edges := make([]edge, len(n.Objects))
+		for i := range n.Objects {
+			edges[i] = edge{
+				segments: []string{"Objects", strconv.Itoa(i)},
+				node:     &n.Objects[i],
+				remove:   cannotRemove("object"),
+			}
+		}
+		return edges, noop
+
+	case *UObject:
+		edges := make([]edge, 0, len(n.Properties)+len(n.Components))
+		for _, e := range propertyEdges(&n.Properties) {
+			e.segments = append([]string{"Properties"}, e.segments...)
+			edges = append(edges, e)
+		}
+		for i := range n.Components {
+			edges = append(edges, edge{
+				segments: []string{"Components", n.Components[i].ComponentKey},
+				node:     &n.Components[i],
+				remove:   removeAt(&n.Components, i),
+			})
+		}
+		return edges, noop
+
+	case *Component:
+		return propertyEdges(&n.Properties), noop
+
+	case *Property:
+		switch v := n.Value.(type) {
+		case StructProperty:
+			edges, value := structValueEdges(v.Value)
+			return edges, func() {
+				v.Value = value()
+				n.Value = v
+			}
+
+		case ArrayProperty:
+			edges := make([]edge, len(v.Items))
+			for i := range v.Items {
+				edges[i] = edge{
+					segments: []string{strconv.Itoa(i)},
+					node:     &v.Items[i],
+					remove:   removeAt(&v.Items, i),
+				}
+			}
+			return edges, func() {
+				v.Count = uint32(len(v.Items))
+				n.Value = v
+			}
+
+		case ArrayStructProperty:
+			edges := make([]edge, len(v.Items))
+			for i := range v.Items {
+				edges[i] = edge{
+					segments: []string{strconv.Itoa(i)},
+					node:     &v.Items[i],
+					remove:   removeAt(&v.Items, i),
+				}
+			}
+			return edges, func() {
+				v.Count = uint32(len(v.Items))
+				n.Value = v
+			}
+
+		case MapProperty:
+			edges := make([]edge, len(v.Values))
+			for i := range v.Values {
+				edges[i] = edge{
+					segments: []string{strconv.Itoa(i)},
+					node:     &v.Values[i],
+					remove:   removeAt(&v.Values, i),
+				}
+			}
+			return edges, func() { n.Value = v }
+
+		case Variables:
+			edges := propertyEdges(&v.Properties)
+			return edges, func() { n.Value = v }
+		}
+
+	case *StructProperty:
+		edges, value := structValueEdges(n.Value)
+		return edges, func() { n.Value = value() }
+
+	case *Actor:
+		return []edge{{
+			segments: []string{"Archive"},
+			node:     &n.Archive,
+			remove:   cannotRemove("archive"),
+		}}, noop
+	}
+
+	return nil, noop
+}
+
+func walkNode(path Path, node Node, fn WalkFunc) error {
+	err := fn(path, node)
+	if err == SkipNode {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	edges, _ := expand(node)
+	for _, e := range edges {
+		err = walkNode(path.Append(e.segments...), e.node, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Walk visits every node of the archive in depth-first order, starting with
+// the archive data itself at the empty path. Nodes are passed as pointers into
+// the archive; use Mutate for changes that affect the shape of the tree.
+func Walk(archive *SaveArchive, fn WalkFunc) error {
+	err := walkNode(Path{}, &archive.Data, fn)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+func mutateNode(path Path, node Node, fn WalkFunc) error {
+	err := fn(path, node)
+	if err == SkipNode {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	edges, store := expand(node)
+	defer store()
+
+	removed := []edge{}
+	for _, e := range edges {
+		err = mutateNode(path.Append(e.segments...), e.node, fn)
+		if err == RemoveNode {
+			removed = append(removed, e)
+			continue
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	for i := len(removed) - 1; i >= 0; i-- {
+		removeErr := removed[i].remove()
+		if removeErr != nil {
+			return fmt.Errorf("%s: %w", path.Append(removed[i].segments...), removeErr)
+		}
+	}
+
+	return err
+}
+
+// Mutate walks the archive like Walk, but additionally allows fn to replace
+// value-typed nodes in place and to return RemoveNode to delete a property,
+// component, array or map item or actor from its parent.
+func Mutate(archive *SaveArchive, fn WalkFunc) error {
+	err := mutateNode(Path{}, &archive.Data, fn)
+	if err == StopWalk {
+		return nil
+	}
+	if err == RemoveNode {
+		return fmt.Errorf("archive data cannot be removed")
+	}
+	return err
+}
+
+// resolve follows path from node and returns every node along the way,
+// starting with node itself, and the edges that lead to them. Nodes held by
+// value in their parents are copies; calling the returned function stores
+// changes made to them back into the tree.
+func resolve(node Node, path Path) ([]Node, []edge, func(), error) {
+	chain := []Node{node}
+	used := []edge{}
+	stores := []func(){}
+	store := func() {
+		for i := len(stores) - 1; i >= 0; i-- {
+			stores[i]()
+		}
+	}
+
+	for len(path) > 0 {
+		edges, nodeStore := expand(node)
+		stores = append(stores, nodeStore)
+
+		var next *edge
+		for i := range edges {
+			if hasPrefix(path, edges[i].segments) {
+				next = &edges[i]
+				break
+			}
+		}
+		if next == nil {
+			return chain, used, store, fmt.Errorf("path not found: %s", path)
+		}
+		node = next.node
+		path = path[len(next.segments):]
+		chain = append(chain, node)
+		used = append(used, *next)
+	}
+
+	return chain, used, store, nil
+}
+
+func hasPrefix(path Path, segments []string) bool {
+	if len(path) < len(segments) {
+		return false
+	}
+	for i, segment := range segments {
+		if path[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// Lookup returns the node addressed by path.
+func Lookup(archive *SaveArchive, path Path) (Node, error) {
+	chain, _, _, err := resolve(&archive.Data, path)
+	if err != nil {
+		return nil, err
+	}
+	return chain[len(chain)-1], nil
+}
@@ -0,0 +1,78 @@
+package world
+
+import (
+	"revision-go/remnant"
+	"strings"
+)
+
+// actorClassPath returns the class of dynamic actors and the object path of
+// the actor object for actors placed in a level.
+func actorClassPath(actor *remnant.Actor) string {
+	if actor.DynamicData != nil {
+		classPath := actor.DynamicData.ClassPath
+		if classPath.Name == "" {
+			return classPath.Path
+		}
+		return classPath.Path + "." + classPath.Name
+	}
+
+	if len(actor.Archive.Objects) > 0 {
+		return actor.Archive.Objects[0].ObjectPath
+	}
+	return ""
+}
+
+func actorProperties(actor *remnant.Actor) []remnant.Property {
+	if len(actor.Archive.Objects) == 0 {
+		return nil
+	}
+	return actor.Archive.Objects[0].Properties
+}
+
+// worldName returns the World_* folder of an asset path.
+func worldName(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "World_") {
+			return segment
+		}
+	}
+	return ""
+}
+
+func zoneName(properties []remnant.Property, className string) string {
+	for _, name := range []string{"Label", "NameID"} {
+		if value, ok := remnant.StringValue(properties, name); ok && value != "" {
+			return value
+		}
+	}
+	return className
+}
+
+func readQuest(id uint64, classPath string, className string, world string, properties []remnant.Property) Quest {
+	quest := Quest{
+		UniqueID:  id,
+		ClassPath: classPath,
+		Name:      className,
+		World:     world,
+	}
+
+	for _, name := range []string{"QuestState", "State"} {
+		if state, ok := remnant.StringValue(properties, name); ok {
+			quest.State = state
+			break
+		}
+	}
+
+	quest.Completed = strings.HasSuffix(quest.State, "Complete") || strings.HasSuffix(quest.State, "Completed") ||
+		remnant.BoolValue(properties, "Completed") || remnant.BoolValue(properties, "bCompleted")
+
+	return quest
+}
+
+func isDefeated(properties []remnant.Property) bool {
+	return remnant.BoolValue(properties, "Defeated") || remnant.BoolValue(properties, "bDefeated")
+}
+
+func isActive(properties []remnant.Property) bool {
+	return remnant.BoolValue(properties, "Active") || remnant.BoolValue(properties, "bActive")
+}
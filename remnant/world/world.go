@@ -0,0 +1,178 @@
+// Package world interprets the actors stored in the persistence containers of
+// world saves (BP_RemnantSaveGame). Actors are recognized by the blueprint
+// names of their classes, e.g. ZoneActor, Quest_Boss_* or *Checkpoint*.
+package world
+
+import (
+	"fmt"
+	"revision-go/remnant"
+	"revision-go/ue"
+	"sort"
+	"strings"
+)
+
+type Zone struct {
+	UniqueID  uint64
+	ClassPath string
+	Name      string
+	World     string
+}
+
+type Quest struct {
+	UniqueID  uint64
+	ClassPath string
+	Name      string
+	World     string
+	// value of the quest state property, empty if the actor has none
+	State     string
+	Completed bool
+}
+
+type Checkpoint struct {
+	UniqueID  uint64
+	ClassPath string
+	Name      string
+	World     string
+	Active    bool
+}
+
+// Report describes one persistence container of a world save, which holds
+// either the campaign or the adventure.
+type Report struct {
+	// path of the property holding the container
+	Path remnant.Path
+	// Campaign, Adventure or empty if the container path does not tell
+	Mode string
+	// the world most zones belong to
+	World          string
+	Zones          []Zone
+	Dungeons       []Zone
+	Events         []Quest
+	Quests         []Quest
+	BossesDefeated []string
+	Checkpoints    []Checkpoint
+	// name of the active checkpoint, empty if none is active
+	Checkpoint string
+}
+
+// ReadReports returns a report for every persistence container of a world save.
+func ReadReports(archive *remnant.SaveArchive) ([]Report, error) {
+	saveData := &archive.Data
+	if saveData.SaveGameClassPath == nil || saveData.SaveGameClassPath.Path != remnant.REMNANT_SAVE_GAME {
+		return nil, fmt.Errorf("not a world save")
+	}
+
+	reports := []Report{}
+	err := remnant.Walk(archive, func(path remnant.Path, node remnant.Node) error {
+		property, ok := node.(*remnant.Property)
+		if !ok {
+			return nil
+		}
+		structProperty, ok := property.Value.(remnant.StructProperty)
+		if !ok {
+			return nil
+		}
+		container, ok := structProperty.Value.(remnant.PersistenceContainer)
+		if !ok {
+			return nil
+		}
+
+		reports = append(reports, readReport(path, &container))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func containerMode(path remnant.Path) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		switch {
+		case strings.Contains(path[i], "Adventure"):
+			return "Adventure"
+		case strings.Contains(path[i], "Campaign"):
+			return "Campaign"
+		}
+	}
+	return ""
+}
+
+func readReport(path remnant.Path, container *remnant.PersistenceContainer) Report {
+	report := Report{
+		Path:           path,
+		Mode:           containerMode(path),
+		Zones:          []Zone{},
+		Dungeons:       []Zone{},
+		Events:         []Quest{},
+		Quests:         []Quest{},
+		BossesDefeated: []string{},
+		Checkpoints:    []Checkpoint{},
+	}
+
+	ids := make([]uint64, 0, len(container.Actors))
+	for id := range container.Actors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	worlds := map[string]int{}
+	for _, id := range ids {
+		actor := container.Actors[id]
+		classPath := actorClassPath(&actor)
+		className := ue.ObjectName(classPath)
+		properties := actorProperties(&actor)
+		world := worldName(classPath)
+
+		switch {
+		case strings.Contains(className, "ZoneActor"):
+			zone := Zone{
+				UniqueID:  id,
+				ClassPath: classPath,
+				Name:      zoneName(properties, className),
+				World:     world,
+			}
+			if world != "" {
+				worlds[world]++
+			}
+			if strings.Contains(zone.Name, "Dungeon") || strings.Contains(classPath, "Dungeon") {
+				report.Dungeons = append(report.Dungeons, zone)
+			} else {
+				report.Zones = append(report.Zones, zone)
+			}
+
+		case strings.HasPrefix(className, "Quest_"):
+			quest := readQuest(id, classPath, className, world, properties)
+			if strings.Contains(className, "Event") {
+				report.Events = append(report.Events, quest)
+			} else {
+				report.Quests = append(report.Quests, quest)
+			}
+			if strings.Contains(className, "Boss") && (quest.Completed || isDefeated(properties)) {
+				report.BossesDefeated = append(report.BossesDefeated, className)
+			}
+
+		case strings.Contains(className, "Checkpoint"):
+			checkpoint := Checkpoint{
+				UniqueID:  id,
+				ClassPath: classPath,
+				Name:      className,
+				World:     world,
+				Active:    isActive(properties),
+			}
+			report.Checkpoints = append(report.Checkpoints, checkpoint)
+			if checkpoint.Active {
+				report.Checkpoint = checkpoint.Name
+			}
+		}
+	}
+
+	for world, count := range worlds {
+		if count > worlds[report.World] || (count == worlds[report.World] && world < report.World) {
+			report.World = world
+		}
+	}
+
+	return report
+}
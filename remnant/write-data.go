@@ -0,0 +1,342 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"revision-go/memory"
+	"revision-go/ue"
+)
+
+func writeSaveHeader(w *bytes.Buffer, header SaveHeader) error {
+	return binary.Write(w, binary.LittleEndian, header)
+}
+
+func writeSaveData(w *bytes.Buffer, saveData *SaveData, hasPackageVersion bool, hasTopLevelAssetPath bool) error {
+	if hasPackageVersion {
+		packageVersion := PackageVersion{}
+		if saveData.PackageVersion != nil {
+			packageVersion = *saveData.PackageVersion
+		}
+		err := binary.Write(w, binary.LittleEndian, packageVersion)
+		if err != nil {
+			return fmt.Errorf("failed to write package version: %w", err)
+		}
+	}
+	if hasTopLevelAssetPath {
+		if saveData.SaveGameClassPath == nil {
+			return fmt.Errorf("failed to write top level asset path: missing save game class path")
+		}
+		err := ue.WriteFTopLevelAssetPath(w, *saveData.SaveGameClassPath)
+		if err != nil {
+			return fmt.Errorf("failed to write top level asset path: %w", err)
+		}
+	}
+
+	// offsets are known only after the objects are written
+	offsetsPos := w.Len()
+	err := binary.Write(w, binary.LittleEndian, OffsetInfo{})
+	if err != nil {
+		return err
+	}
+
+	names := newNameTable(saveData.NamesTable)
+
+	err = writeObjectsData(w, saveData, names)
+	if err != nil {
+		return fmt.Errorf("failed to write objects: %w", err)
+	}
+
+	objectsOffset := w.Len()
+	err = writeObjects(w, saveData, names)
+	if err != nil {
+		return fmt.Errorf("failed to write objects: %w", err)
+	}
+
+	namesOffset := w.Len()
+	err = writeNamesTable(w, names.names)
+	if err != nil {
+		return fmt.Errorf("failed to write names table: %w", err)
+	}
+
+	offsets := bytes.Buffer{}
+	err = binary.Write(&offsets, binary.LittleEndian, OffsetInfo{
+		Names:   uint64(namesOffset),
+		Version: saveData.Version,
+		Objects: uint64(objectsOffset),
+	})
+	if err != nil {
+		return err
+	}
+	copy(w.Bytes()[offsetsPos:], offsets.Bytes())
+
+	return nil
+}
+
+// WriteSaveArchive encodes the archive in the layout read by ReadSaveArchive.
+// Property sizes and table offsets are recomputed; the crc and size in the
+// header are filled in by WriteData.
+func WriteSaveArchive(w io.Writer, archive *SaveArchive) error {
+	buf := bytes.Buffer{}
+
+	err := writeSaveHeader(&buf, archive.Header)
+	if err != nil {
+		return err
+	}
+
+	err = writeSaveData(&buf, &archive.Data, true, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeObject(w *bytes.Buffer, saveData *SaveData, object *UObject, objectID uint32, names *nameTable) error {
+	var wasLoaded uint8
+	if object.WasLoaded {
+		wasLoaded = 1
+	}
+	err := w.WriteByte(wasLoaded)
+	if err != nil {
+		return err
+	}
+
+	if !object.WasLoaded || objectID != 0 || saveData.SaveGameClassPath == nil {
+		err = ue.WriteFString(w, object.ObjectPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !object.WasLoaded {
+		loadedData := UObjectLoadedData{}
+		if object.LoadedData != nil {
+			loadedData = *object.LoadedData
+		}
+
+		err = writeName(w, names, loadedData.Name)
+		if err != nil {
+			return err
+		}
+
+		err = memory.WriteInt[uint32](w, loadedData.OuterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeNamesTable(w *bytes.Buffer, names []string) error {
+	err := memory.WriteInt[int32](w, int32(len(names)))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = ue.WriteFString(w, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeVariable(w *bytes.Buffer, property *Property, names *nameTable) error {
+	err := writeName(w, names, property.Name)
+	if err != nil {
+		return fmt.Errorf("failed to write variable name: %w", err)
+	}
+
+	var varTypeEnumValue uint8
+	found := false
+	for enumValue, typeName := range VarTypeNames {
+		if typeName == property.Type {
+			varTypeEnumValue = enumValue
+			found = true
+			break
+		}
+	}
+	if !found || varTypeEnumValue == VarTypeNone {
+		return fmt.Errorf("unknown variable type: %s", property.Type)
+	}
+
+	err = w.WriteByte(varTypeEnumValue)
+	if err != nil {
+		return fmt.Errorf("failed to write variable type: %w", err)
+	}
+
+	switch varTypeEnumValue {
+	case VarTypeBool:
+		value, ok := property.Value.(bool)
+		if !ok {
+			break
+		}
+		var varValue uint32
+		if value {
+			varValue = 1
+		}
+		return memory.WriteInt[uint32](w, varValue)
+
+	case VarTypeInt:
+		value, ok := property.Value.(int32)
+		if !ok {
+			break
+		}
+		return memory.WriteInt[uint32](w, uint32(value))
+
+	case VarTypeFloat:
+		value, ok := property.Value.(float32)
+		if !ok {
+			break
+		}
+		return memory.WriteInt[uint32](w, math.Float32bits(value))
+
+	case VarTypeName:
+		value, ok := property.Value.(string)
+		if !ok {
+			break
+		}
+		return writeName(w, names, value)
+	}
+
+	return fmt.Errorf("unexpected value %T for variable %s (%s)", property.Value, property.Name, property.Type)
+}
+
+func writeVariables(w *bytes.Buffer, variables *Variables, names *nameTable) error {
+	err := writeName(w, names, variables.Name)
+	if err != nil {
+		return fmt.Errorf("failed to write variable name: %w", err)
+	}
+
+	err = memory.WriteInt[uint64](w, 0)
+	if err != nil {
+		return err
+	}
+
+	err = memory.WriteInt[uint32](w, uint32(len(variables.Properties)))
+	if err != nil {
+		return err
+	}
+
+	for i := range variables.Properties {
+		err = writeVariable(w, &variables.Properties[i], names)
+		if err != nil {
+			return fmt.Errorf("failed to write property: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func writeComponents(w *bytes.Buffer, components []Component, names *nameTable) error {
+	err := memory.WriteInt[uint32](w, uint32(len(components)))
+	if err != nil {
+		return err
+	}
+
+	for i := range components {
+		component := &components[i]
+
+		err = ue.WriteFString(w, component.ComponentKey)
+		if err != nil {
+			return err
+		}
+
+		data := bytes.Buffer{}
+		variables, isVariables := componentVariables(component)
+		if isVariables && variablesComponents[component.ComponentKey] {
+			err = writeVariables(&data, variables, names)
+		} else {
+			err = writeProperties(&data, component.Properties, names)
+		}
+		if err != nil {
+			return fmt.Errorf("component %s: %w", component.ComponentKey, err)
+		}
+		data.Write(component.UnreadData)
+
+		err = writeSizedBlock(w, data.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// componentVariables returns the variables of components that readComponents
+// stores as a single Variables property.
+func componentVariables(component *Component) (*Variables, bool) {
+	if len(component.Properties) != 1 || component.Properties[0].Name != component.ComponentKey {
+		return nil, false
+	}
+
+	variables, ok := component.Properties[0].Value.(Variables)
+	return &variables, ok
+}
+
+func writeObjectsData(w *bytes.Buffer, saveData *SaveData, names *nameTable) error {
+	for i := range saveData.Objects {
+		object := &saveData.Objects[i]
+
+		err := memory.WriteInt[uint32](w, uint32(i))
+		if err != nil {
+			return fmt.Errorf("failed to write object id: %w", err)
+		}
+
+		err = writeObjectData(w, object, names)
+		if err != nil {
+			return fmt.Errorf("failed to write object data: %w", err)
+		}
+
+		if object.Components == nil {
+			w.WriteByte(0)
+			continue
+		}
+
+		w.WriteByte(1)
+		err = writeComponents(w, object.Components, names)
+		if err != nil {
+			return fmt.Errorf("failed to write components: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func writeObjects(w *bytes.Buffer, saveData *SaveData, names *nameTable) error {
+	err := memory.WriteInt[int32](w, int32(len(saveData.Objects)))
+	if err != nil {
+		return err
+	}
+
+	for i := range saveData.Objects {
+		err = writeObject(w, saveData, &saveData.Objects[i], uint32(i), names)
+		if err != nil {
+			return fmt.Errorf("failed to write object %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func writeObjectData(w *bytes.Buffer, object *UObject, names *nameTable) error {
+	if object.Properties == nil {
+		return memory.WriteInt[uint32](w, 0)
+	}
+
+	data := bytes.Buffer{}
+	err := writeProperties(&data, object.Properties, names)
+	if err != nil {
+		return err
+	}
+	data.Write(object.UnreadData)
+
+	return writeSizedBlock(w, data.Bytes())
+}
@@ -0,0 +1,771 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"revision-go/memory"
+	"revision-go/ue"
+	"strconv"
+	"strings"
+)
+
+type nameTable struct {
+	names []string
+	index map[string]uint16
+}
+
+func newNameTable(names []string) *nameTable {
+	table := &nameTable{
+		names: make([]string, 0, len(names)),
+		index: make(map[string]uint16, len(names)),
+	}
+	for _, name := range names {
+		table.add(name)
+	}
+	return table
+}
+
+func (t *nameTable) add(name string) uint16 {
+	index := uint16(len(t.names))
+	t.names = append(t.names, name)
+	if _, ok := t.index[name]; !ok {
+		t.index[name] = index
+	}
+	return index
+}
+
+func (t *nameTable) lookup(name string) (ue.FName, error) {
+	index, ok := t.index[name]
+	if !ok {
+		base, number, isNumbered := splitNameNumber(name)
+		if baseIndex, found := t.index[base]; found && isNumbered {
+			return ue.FName{Index: baseIndex, Number: number, Value: name}, nil
+		}
+
+		if len(t.names) >= 1<<15 {
+			return ue.FName{}, fmt.Errorf("names table is full, cannot add %q", name)
+		}
+		index = t.add(name)
+	}
+
+	return ue.FName{Index: index, Value: name}, nil
+}
+
+// splitNameNumber is the inverse of the numbered name formatting in readName.
+func splitNameNumber(name string) (string, int32, bool) {
+	separator := strings.LastIndexByte(name, '_')
+	if separator < 0 {
+		return name, 0, false
+	}
+
+	digits := name[separator+1:]
+	if digits == "" || (len(digits) > 1 && digits[0] == '0') {
+		return name, 0, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return name, 0, false
+		}
+	}
+
+	number, err := strconv.ParseInt(digits, 10, 32)
+	if err != nil || number >= math.MaxInt32 {
+		return name, 0, false
+	}
+
+	return name[:separator], int32(number) + 1, true
+}
+
+func writeName(w *bytes.Buffer, names *nameTable, name string) error {
+	fName, err := names.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	return ue.WriteFName(w, fName)
+}
+
+func writePadding(w *bytes.Buffer, raw bool) {
+	if !raw {
+		w.WriteByte(0)
+	}
+}
+
+func writeObjectProperty(w *bytes.Buffer, value ObjectProperty, raw bool) error {
+	writePadding(w, raw)
+	return memory.WriteInt[int32](w, value.ObjectID)
+}
+
+func writeByteProperty(w *bytes.Buffer, value interface{}, names *nameTable, raw bool) (int, error) {
+	if raw {
+		byteData, ok := value.(uint8)
+		if !ok {
+			return 0, fmt.Errorf("writeByteProperty: unexpected value %T", value)
+		}
+		return 0, w.WriteByte(byteData)
+	}
+
+	start := w.Len()
+	switch v := value.(type) {
+	case uint8:
+		err := writeName(w, names, "None")
+		if err != nil {
+			return 0, err
+		}
+		w.WriteByte(0)
+		header := w.Len() - start
+
+		return header, w.WriteByte(v)
+
+	case EnumProperty:
+		err := writeName(w, names, v.EnumType)
+		if err != nil {
+			return 0, err
+		}
+		w.WriteByte(0)
+		header := w.Len() - start
+
+		return header, writeName(w, names, v.EnumValue)
+	}
+
+	return 0, fmt.Errorf("writeByteProperty: unexpected value %T", value)
+}
+
+func writeArrayProperty(w *bytes.Buffer, name string, value interface{}, names *nameTable) (int, error) {
+	start := w.Len()
+
+	if arrayStructProperty, ok := value.(ArrayStructProperty); ok {
+		err := writeName(w, names, "StructProperty")
+		if err != nil {
+			return 0, err
+		}
+		w.WriteByte(0)
+		header := w.Len() - start
+
+		err = memory.WriteInt[uint32](w, uint32(len(arrayStructProperty.Items)))
+		if err != nil {
+			return 0, err
+		}
+
+		items := bytes.Buffer{}
+		for _, item := range arrayStructProperty.Items {
+			err = writeStructPropertyData(&items, arrayStructProperty.ElementType, item.Value, names)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		err = writeArrayStructHeader(w, name, arrayStructProperty, uint32(items.Len()), names)
+		if err != nil {
+			return 0, err
+		}
+		w.Write(items.Bytes())
+
+		return header, nil
+	}
+
+	arrayProperty, ok := value.(ArrayProperty)
+	if !ok {
+		return 0, fmt.Errorf("writeArrayProperty: unexpected value %T", value)
+	}
+
+	err := writeName(w, names, arrayProperty.ElementType)
+	if err != nil {
+		return 0, err
+	}
+	w.WriteByte(0)
+	header := w.Len() - start
+
+	err = memory.WriteInt[uint32](w, uint32(len(arrayProperty.Items)))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range arrayProperty.Items {
+		_, err = writePropertyValue(w, name, arrayProperty.ElementType, item, names, true)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return header, nil
+}
+
+func writeArrayStructHeader(w *bytes.Buffer, name string, value ArrayStructProperty, size uint32, names *nameTable) error {
+	err := writeName(w, names, name)
+	if err != nil {
+		return err
+	}
+
+	err = writeName(w, names, "StructProperty")
+	if err != nil {
+		return err
+	}
+
+	err = memory.WriteInt[uint32](w, size)
+	if err != nil {
+		return err
+	}
+
+	err = memory.WriteInt[uint32](w, 0)
+	if err != nil {
+		return err
+	}
+
+	err = writeName(w, names, value.ElementType)
+	if err != nil {
+		return err
+	}
+
+	err = ue.WriteGuid(w, value.GUID)
+	if err != nil {
+		return err
+	}
+
+	return w.WriteByte(0)
+}
+
+func writeSizedBlock(w *bytes.Buffer, data []byte) error {
+	err := memory.WriteInt[uint32](w, uint32(len(data)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func writeStructPropertyData(w *bytes.Buffer, structName string, value interface{}, names *nameTable) error {
+	switch structName {
+	case "SoftClassPath", "SoftObjectPath":
+		str, ok := value.(string)
+		if !ok {
+			break
+		}
+		return writeStrProperty(w, str, true)
+
+	case "Timespan", "DateTime":
+		ticks, ok := value.(int64)
+		if !ok {
+			break
+		}
+		return memory.WriteInt[int64](w, ticks)
+
+	case "Guid":
+		guid, ok := value.(ue.FGuid)
+		if !ok {
+			break
+		}
+		return ue.WriteGuid(w, guid)
+
+	case "Vector":
+		vector, ok := value.(ue.FVector)
+		if !ok {
+			break
+		}
+		return ue.WriteFVector(w, vector)
+
+	case "PersistenceBlob":
+		switch blob := value.(type) {
+		case PersistenceBlob:
+			archive := bytes.Buffer{}
+			err := writeSaveData(&archive, &blob.Archive, true, false)
+			if err != nil {
+				return err
+			}
+			return writeSizedBlock(w, archive.Bytes())
+
+		case PersistenceContainer:
+			container, err := writePersistenceContainer(&blob)
+			if err != nil {
+				return err
+			}
+			return writeSizedBlock(w, container)
+		}
+
+	default:
+		properties, ok := value.([]Property)
+		if !ok {
+			break
+		}
+		return writeProperties(w, properties, names)
+	}
+
+	return fmt.Errorf("writeStructPropertyData: unexpected value %T for %s", value, structName)
+}
+
+func writeStructProperty(w *bytes.Buffer, value interface{}, names *nameTable, raw bool) (int, error) {
+	if raw {
+		reference, ok := value.(StructReference)
+		if !ok {
+			return 0, fmt.Errorf("writeStructProperty: unexpected value %T", value)
+		}
+		return 0, ue.WriteGuid(w, reference.GUID)
+	}
+
+	structProperty, ok := value.(StructProperty)
+	if !ok {
+		return 0, fmt.Errorf("writeStructProperty: unexpected value %T", value)
+	}
+
+	start := w.Len()
+	err := writeName(w, names, structProperty.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	err = ue.WriteGuid(w, structProperty.GUID)
+	if err != nil {
+		return 0, err
+	}
+	w.WriteByte(0)
+	header := w.Len() - start
+
+	err = writeStructPropertyData(w, structProperty.Name, structProperty.Value, names)
+	if err != nil {
+		return 0, err
+	}
+
+	return header, nil
+}
+
+func writeEnumProperty(w *bytes.Buffer, value EnumProperty, names *nameTable) (int, error) {
+	start := w.Len()
+	err := writeName(w, names, value.EnumType)
+	if err != nil {
+		return 0, fmt.Errorf("writeEnumProperty: %w", err)
+	}
+	w.WriteByte(0)
+	header := w.Len() - start
+
+	err = writeName(w, names, value.EnumValue)
+	if err != nil {
+		return 0, fmt.Errorf("writeEnumProperty: %w", err)
+	}
+
+	return header, nil
+}
+
+func writeTextProperty(w *bytes.Buffer, value TextProperty, raw bool) error {
+	writePadding(w, raw)
+
+	err := memory.WriteInt[uint32](w, value.Flags)
+	if err != nil {
+		return err
+	}
+
+	err = w.WriteByte(value.HistoryType)
+	if err != nil {
+		return err
+	}
+
+	switch value.HistoryType {
+	case 0:
+		data, ok := value.Data.(TextPropertyData)
+		if !ok {
+			return fmt.Errorf("writeTextProperty: unexpected data %T", value.Data)
+		}
+
+		for _, str := range []string{data.Namespace, data.Key, data.SourceString} {
+			err = ue.WriteFString(w, str)
+			if err != nil {
+				return err
+			}
+		}
+	case 255:
+		if value.Data == nil {
+			return memory.WriteInt[uint32](w, 0)
+		}
+
+		data, ok := value.Data.(TextData)
+		if !ok {
+			return fmt.Errorf("writeTextProperty: unexpected data %T", value.Data)
+		}
+
+		err = memory.WriteInt[uint32](w, 1)
+		if err != nil {
+			return err
+		}
+
+		return ue.WriteFString(w, data.Data)
+	default:
+		return fmt.Errorf("writeTextProperty: unsupported history type %d", value.HistoryType)
+	}
+
+	return nil
+}
+
+func writeMapProperty(w *bytes.Buffer, name string, value MapProperty, names *nameTable) (int, error) {
+	start := w.Len()
+
+	err := writeName(w, names, value.KeyType)
+	if err != nil {
+		return 0, fmt.Errorf("writeMapProperty: %w", err)
+	}
+
+	err = writeName(w, names, value.ValueType)
+	if err != nil {
+		return 0, fmt.Errorf("writeMapProperty: %w", err)
+	}
+	w.WriteByte(0)
+	header := w.Len() - start
+
+	// number of removed entries, always empty in save files
+	err = memory.WriteInt[uint32](w, 0)
+	if err != nil {
+		return 0, fmt.Errorf("writeMapProperty: %w", err)
+	}
+
+	err = memory.WriteInt[int32](w, int32(len(value.Values)))
+	if err != nil {
+		return 0, fmt.Errorf("writeMapProperty: %w", err)
+	}
+
+	for _, entry := range value.Values {
+		_, err = writePropertyValue(w, name, value.KeyType, entry.Key, names, true)
+		if err != nil {
+			return 0, fmt.Errorf("writeMapProperty: %w", err)
+		}
+		_, err = writePropertyValue(w, name, value.ValueType, entry.Value, names, true)
+		if err != nil {
+			return 0, fmt.Errorf("writeMapProperty: %w", err)
+		}
+	}
+
+	return header, nil
+}
+
+func writePersistenceContainer(container *PersistenceContainer) ([]byte, error) {
+	w := bytes.Buffer{}
+
+	err := memory.WriteInt[uint32](&w, container.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	// index and dynamic data offsets, filled in below
+	w.Write(make([]byte, 8))
+
+	ids := sortedActorIDs(container.Actors)
+
+	actorInfo := []ue.FInfo{}
+	for _, id := range ids {
+		actor := container.Actors[id]
+		if actor.DynamicOnly {
+			continue
+		}
+
+		actorBytes, err := writeActor(&actor)
+		if err != nil {
+			return nil, fmt.Errorf("writeActor %d: %w", id, err)
+		}
+
+		actorInfo = append(actorInfo, ue.FInfo{
+			UniqueID: id,
+			Offset:   uint32(w.Len()),
+			Size:     uint32(len(actorBytes)),
+		})
+		w.Write(actorBytes)
+	}
+
+	indexOffset := uint32(w.Len())
+	err = memory.WriteInt[uint32](&w, uint32(len(actorInfo)))
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range actorInfo {
+		err = ue.WriteFInfo(&w, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = memory.WriteInt[uint32](&w, uint32(len(container.Destroyed)))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range container.Destroyed {
+		err = memory.WriteInt[uint64](&w, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dynamicOffset := uint32(w.Len())
+	dynamicActors := []*DynamicActor{}
+	for _, id := range ids {
+		if dynamicData := container.Actors[id].DynamicData; dynamicData != nil {
+			dynamicActors = append(dynamicActors, dynamicData)
+		}
+	}
+
+	err = memory.WriteInt[uint32](&w, uint32(len(dynamicActors)))
+	if err != nil {
+		return nil, err
+	}
+	for _, dynamicActor := range dynamicActors {
+		err = writeDynamicActor(&w, dynamicActor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := w.Bytes()
+	binary.LittleEndian.PutUint32(data[4:], indexOffset)
+	binary.LittleEndian.PutUint32(data[8:], dynamicOffset)
+
+	return data, nil
+}
+
+func writeActor(actor *Actor) ([]byte, error) {
+	w := bytes.Buffer{}
+
+	if actor.Transform == nil {
+		err := memory.WriteInt[uint32](&w, 0)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := memory.WriteInt[uint32](&w, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ue.WriteFTransform(&w, *actor.Transform)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writeSaveData(&w, &actor.Archive, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+func writeDynamicActor(w *bytes.Buffer, dynamicActor *DynamicActor) error {
+	err := memory.WriteInt[uint64](w, dynamicActor.UniqueID)
+	if err != nil {
+		return fmt.Errorf("writeDynamicActor: %w", err)
+	}
+
+	transform := ue.FTransform{}
+	if dynamicActor.Transform != nil {
+		transform = *dynamicActor.Transform
+	}
+	err = ue.WriteFTransform(w, transform)
+	if err != nil {
+		return fmt.Errorf("writeDynamicActor: %w", err)
+	}
+
+	err = ue.WriteFTopLevelAssetPath(w, dynamicActor.ClassPath)
+	if err != nil {
+		return fmt.Errorf("writeDynamicActor: %w", err)
+	}
+
+	return nil
+}
+
+func writeNumProperty[T Number](w *bytes.Buffer, value interface{}, raw bool) error {
+	varData, ok := value.(T)
+	if !ok {
+		return fmt.Errorf("writeNumProperty: unexpected value %T", value)
+	}
+
+	writePadding(w, raw)
+	return binary.Write(w, binary.LittleEndian, varData)
+}
+
+func writeBoolProperty(w *bytes.Buffer, value bool, raw bool) error {
+	var varData uint8
+	if value {
+		varData = 1
+	}
+
+	w.WriteByte(varData)
+	writePadding(w, raw)
+	return nil
+}
+
+func writeStrProperty(w *bytes.Buffer, value string, raw bool) error {
+	writePadding(w, raw)
+	return ue.WriteFString(w, value)
+}
+
+func writeNameProperty(w *bytes.Buffer, value string, names *nameTable, raw bool) error {
+	writePadding(w, raw)
+	return writeName(w, names, value)
+}
+
+// writePropertyValue is the counterpart of getPropertyValue. It returns the
+// number of written bytes that belong to the property tag rather than to the
+// value, so that the caller can compute the property size.
+func writePropertyValue(w *bytes.Buffer, name string, varType string, value interface{}, names *nameTable, raw bool) (int, error) {
+	header := 0
+	if !raw {
+		header = 1
+	}
+
+	var err error
+	switch varType {
+	case "IntProperty":
+		err = writeNumProperty[int32](w, value, raw)
+
+	case "Int16Property":
+		err = writeNumProperty[int16](w, value, raw)
+
+	case "Int64Property":
+		err = writeNumProperty[int64](w, value, raw)
+
+	case "UInt64Property":
+		err = writeNumProperty[uint64](w, value, raw)
+
+	case "FloatProperty":
+		err = writeNumProperty[float32](w, value, raw)
+
+	case "DoubleProperty":
+		err = writeNumProperty[float64](w, value, raw)
+
+	case "UInt16Property":
+		err = writeNumProperty[uint16](w, value, raw)
+
+	case "UInt32Property":
+		err = writeNumProperty[uint32](w, value, raw)
+
+	case "SoftClassPath", "SoftObjectProperty", "StrProperty":
+		str, ok := value.(string)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		err = writeStrProperty(w, str, raw)
+
+	case "BoolProperty":
+		boolean, ok := value.(bool)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		start := w.Len()
+		err = writeBoolProperty(w, boolean, raw)
+		if !raw {
+			header = w.Len() - start
+		}
+
+	case "MapProperty":
+		mapProperty, ok := value.(MapProperty)
+		if !ok || raw {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		header, err = writeMapProperty(w, name, mapProperty, names)
+
+	case "EnumProperty":
+		enumProperty, ok := value.(EnumProperty)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		header, err = writeEnumProperty(w, enumProperty, names)
+
+	case "TextProperty":
+		textProperty, ok := value.(TextProperty)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		err = writeTextProperty(w, textProperty, raw)
+
+	case "NameProperty":
+		str, ok := value.(string)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		err = writeNameProperty(w, str, names, raw)
+
+	case "ArrayProperty":
+		header, err = writeArrayProperty(w, name, value, names)
+
+	case "StructProperty":
+		header, err = writeStructProperty(w, value, names, raw)
+
+	case "ObjectProperty":
+		objectProperty, ok := value.(ObjectProperty)
+		if !ok {
+			return 0, fmt.Errorf("%s: unexpected value %T", varType, value)
+		}
+		err = writeObjectProperty(w, objectProperty, raw)
+
+	case "ByteProperty":
+		header, err = writeByteProperty(w, value, names, raw)
+
+	case "None":
+		return 0, nil
+
+	default:
+		return 0, fmt.Errorf("property type is not supported yet: %s", varType)
+	}
+
+	return header, err
+}
+
+// propertyValueBytes encodes the value of a tagged property and returns the
+// encoded bytes together with the size that has to be stored in the tag.
+func propertyValueBytes(property *Property, names *nameTable) ([]byte, uint32, error) {
+	w := bytes.Buffer{}
+
+	if property.Name == "FowVisitedCoordinates" {
+		data, ok := property.Value.([]byte)
+		if !ok || len(data) < 19 {
+			return nil, 0, fmt.Errorf("unexpected value %T for %s", property.Value, property.Name)
+		}
+		return data, uint32(len(data) - 19), nil
+	}
+
+	header, err := writePropertyValue(&w, property.Name, property.Type, property.Value, names, false)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to write variable data (%s %s): %w", property.Name, property.Type, err)
+	}
+
+	return w.Bytes(), uint32(w.Len() - header), nil
+}
+
+func writeProperty(w *bytes.Buffer, property *Property, names *nameTable) error {
+	err := writeName(w, names, property.Name)
+	if err != nil {
+		return fmt.Errorf("failed to write variable name: %w", err)
+	}
+
+	err = writeName(w, names, property.Type)
+	if err != nil {
+		return fmt.Errorf("failed to write variable type: %w", err)
+	}
+
+	value, size, err := propertyValueBytes(property, names)
+	if err != nil {
+		return err
+	}
+
+	err = memory.WriteInt[uint32](w, size)
+	if err != nil {
+		return err
+	}
+
+	err = memory.WriteInt[uint32](w, property.Index)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(value)
+	return err
+}
+
+func writeProperties(w *bytes.Buffer, properties []Property, names *nameTable) error {
+	for i := range properties {
+		err := writeProperty(w, &properties[i], names)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeName(w, names, "None")
+}
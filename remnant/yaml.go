@@ -0,0 +1,480 @@
+package remnant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"revision-go/internal/tree"
+	"revision-go/yaml"
+	"strconv"
+	"strings"
+)
+
+// The YAML representation is the JSON document with property lists written
+// as mappings from property names to values, see docs/yaml-format.md. Only
+// values whose type cannot be told from the value itself are tagged.
+
+// propertyTypes are the property types understood by decodePropertyValue,
+// besides the variables components.
+var propertyTypes = map[string]bool{
+	"IntProperty": true, "Int16Property": true, "Int64Property": true, "UInt16Property": true,
+	"UInt32Property": true, "UInt64Property": true, "FloatProperty": true, "FloatProeprty": true,
+	"DoubleProperty": true, "BoolProperty": true, "ByteProperty": true, "StrProperty": true,
+	"NameProperty": true, "SoftObjectProperty": true, "SoftClassPath": true, "EnumProperty": true,
+	"TextProperty": true, "ObjectProperty": true, "StructProperty": true, "ArrayProperty": true,
+	"MapProperty": true, "None": true,
+}
+
+func isPropertyType(name string) bool {
+	return propertyTypes[name] || variablesComponents[name]
+}
+
+// leafStructs are the structs whose value is not a list of properties, see
+// decodeStructValue.
+var leafStructs = map[string]bool{
+	"SoftClassPath": true, "SoftObjectPath": true, "Timespan": true, "DateTime": true,
+	"Guid": true, "Vector": true, "PersistenceBlob": true,
+}
+
+// the types of untagged scalars
+type scalarTypes struct {
+	Int, Float, Bool, String string
+}
+
+var (
+	defaultTypes   = scalarTypes{"IntProperty", "FloatProperty", "BoolProperty", "StrProperty"}
+	variablesTypes = scalarTypes{"IntProperty", "FloatProeprty", "BoolProperty", "NameProperty"}
+)
+
+var (
+	propertyKeyPattern = regexp.MustCompile(`^(.*)\[([0-9]+)\]$`)
+	tagPattern         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+const zeroGUID = `{"A":0,"B":0,"C":0,"D":0}`
+
+func isZeroGUID(n *tree.Node) bool {
+	if n == nil {
+		return false
+	}
+	data, err := n.JSON()
+	return err == nil && string(data) == zeroGUID
+}
+
+func mustParseJSON(data string) *tree.Node {
+	n, err := tree.FromJSON([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func tagged(n *tree.Node, tag string) *tree.Node {
+	n.Tag = tag
+	return n
+}
+
+// compactNode compacts the property lists below a node of the JSON document.
+func compactNode(n *tree.Node) *tree.Node {
+	for i, item := range n.Items {
+		if n.Kind == tree.MappingNode && n.Keys[i] == "Properties" {
+			n.Items[i] = compactProperties(item, defaultTypes)
+		} else {
+			n.Items[i] = compactNode(item)
+		}
+	}
+	return n
+}
+
+// compactProperties turns a list of properties into a mapping, unless the
+// list cannot be one because names repeat.
+func compactProperties(n *tree.Node, types scalarTypes) *tree.Node {
+	if n.Kind != tree.SequenceNode {
+		return compactNode(n)
+	}
+
+	keys := map[string]bool{}
+	for _, property := range n.Items {
+		key := property.Get("Name").Value
+		if index := property.Get("Index").Value; index != "0" {
+			key = fmt.Sprintf("%s[%s]", key, index)
+		}
+		if keys[key] || propertyKeyPattern.MatchString(property.Get("Name").Value) {
+			return compactNode(n)
+		}
+		keys[key] = true
+	}
+
+	properties := tree.NewMapping()
+	for _, property := range n.Items {
+		key := property.Get("Name").Value
+		if index := property.Get("Index").Value; index != "0" {
+			key = fmt.Sprintf("%s[%s]", key, index)
+		}
+		properties.Append(key, compactProperty(property, types))
+	}
+	return properties
+}
+
+// floatText makes sure a number is read back as a float.
+func floatText(text string) string {
+	if strings.ContainsAny(text, ".") {
+		return text
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		return text[:i] + ".0" + text[i:]
+	}
+	return text + ".0"
+}
+
+func compactEnum(n *tree.Node, propertyType string) *tree.Node {
+	enumType, enumValue := n.Get("EnumType"), n.Get("EnumValue")
+	if enumType != nil && enumValue != nil && len(n.Keys) == 2 &&
+		strings.HasPrefix(enumValue.Value, enumType.Value+"::") {
+		return tagged(tree.NewString(enumValue.Value), propertyType)
+	}
+	return tagged(compactNode(n), propertyType)
+}
+
+func compactProperty(property *tree.Node, types scalarTypes) *tree.Node {
+	name := property.Get("Name").Value
+	propertyType := property.Get("Type").Value
+	value := property.Get("Value")
+
+	if name == "FowVisitedCoordinates" {
+		return tagged(value, propertyType)
+	}
+
+	switch {
+	case propertyType == types.Int || propertyType == types.Bool || propertyType == types.String:
+		return value
+
+	case propertyType == types.Float:
+		value.Value = floatText(value.Value)
+		return value
+
+	case (propertyType == "EnumProperty" || propertyType == "ByteProperty") && value.Kind == tree.MappingNode:
+		return compactEnum(value, propertyType)
+
+	case propertyType == "StructProperty":
+		structName := value.Get("Name").Value
+		structValue := compactStructValue(structName, value.Get("Value"))
+		if isZeroGUID(value.Get("GUID")) && tagPattern.MatchString(structName) && !isPropertyType(structName) {
+			return tagged(structValue, structName)
+		}
+
+		long := tree.NewMapping()
+		long.Append("Name", value.Get("Name"))
+		long.Append("GUID", value.Get("GUID"))
+		long.Append("Value", structValue)
+		return tagged(long, propertyType)
+
+	case propertyType == "ArrayProperty" && value.Get("GUID") != nil:
+		elementType := value.Get("ElementType").Value
+		items := tree.NewSequence()
+		for _, item := range value.Get("Items").Items {
+			items.Items = append(items.Items, compactStructValue(elementType, item.Get("Value")))
+		}
+		if isZeroGUID(value.Get("GUID")) && tagPattern.MatchString(elementType) && !isPropertyType(elementType) {
+			return tagged(items, elementType)
+		}
+
+		long := tree.NewMapping()
+		long.Append("ElementType", value.Get("ElementType"))
+		long.Append("GUID", value.Get("GUID"))
+		long.Append("Items", items)
+		return tagged(long, propertyType)
+
+	case propertyType == "ArrayProperty":
+		elementType := value.Get("ElementType").Value
+		items := value.Get("Items")
+		for i, item := range items.Items {
+			if elementType == "EnumProperty" && item.Kind == tree.MappingNode {
+				items.Items[i] = compactEnum(item, "")
+				items.Items[i].Tag = ""
+			} else {
+				items.Items[i] = compactNode(item)
+			}
+		}
+		return tagged(items, elementType)
+
+	case variablesComponents[propertyType] && value.Kind == tree.MappingNode:
+		variables := tree.NewMapping()
+		variables.Append("Name", value.Get("Name"))
+		variables.Append("Properties", compactProperties(value.Get("Properties"), variablesTypes))
+		return tagged(variables, propertyType)
+	}
+
+	return tagged(compactNode(value), propertyType)
+}
+
+func compactStructValue(structName string, value *tree.Node) *tree.Node {
+	if !leafStructs[structName] && value.Kind == tree.SequenceNode {
+		return compactProperties(value, defaultTypes)
+	}
+	return compactNode(value)
+}
+
+// expandNode is the counterpart of compactNode.
+func expandNode(n *tree.Node) (*tree.Node, error) {
+	if n.Tag != "" {
+		return nil, fmt.Errorf("line %d: unexpected tag !%s", n.Line, n.Tag)
+	}
+
+	for i, item := range n.Items {
+		var err error
+		if n.Kind == tree.MappingNode && n.Keys[i] == "Properties" {
+			n.Items[i], err = expandProperties(item, defaultTypes)
+		} else {
+			n.Items[i], err = expandNode(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func expandProperties(n *tree.Node, types scalarTypes) (*tree.Node, error) {
+	if n.Kind != tree.MappingNode {
+		return expandNode(n)
+	}
+
+	properties := tree.NewSequence()
+	for i, key := range n.Keys {
+		name, index := key, "0"
+		if match := propertyKeyPattern.FindStringSubmatch(key); match != nil {
+			name, index = match[1], match[2]
+		}
+
+		propertyType, value, err := expandProperty(name, n.Items[i], types)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		property := tree.NewMapping()
+		property.Append("Name", tree.NewString(name))
+		property.Append("Index", tree.NewScalar(index))
+		property.Append("Type", tree.NewString(propertyType))
+		property.Append("Size", tree.NewScalar("0"))
+		property.Append("Value", value)
+		properties.Items = append(properties.Items, property)
+	}
+	return properties, nil
+}
+
+func untagged(n *tree.Node) *tree.Node {
+	copy := *n
+	copy.Tag = ""
+	return &copy
+}
+
+func expandEnum(n *tree.Node) (*tree.Node, error) {
+	if n.Kind != tree.ScalarNode {
+		return expandNode(untagged(n))
+	}
+
+	i := strings.Index(n.Value, "::")
+	if i < 0 {
+		return nil, fmt.Errorf("line %d: enum value %q is not of the form Type::Value", n.Line, n.Value)
+	}
+	enum := tree.NewMapping()
+	enum.Append("EnumType", tree.NewString(n.Value[:i]))
+	enum.Append("EnumValue", tree.NewString(n.Value))
+	return enum, nil
+}
+
+func structJSON(structName string, guid *tree.Node, value *tree.Node) (*tree.Node, error) {
+	structValue, err := expandStructValue(structName, value)
+	if err != nil {
+		return nil, err
+	}
+
+	n := tree.NewMapping()
+	n.Append("Name", tree.NewString(structName))
+	n.Append("GUID", guid)
+	n.Append("Value", structValue)
+	n.Append("Size", tree.NewScalar("0"))
+	return n, nil
+}
+
+func structArrayJSON(elementType string, guid *tree.Node, items *tree.Node) (*tree.Node, error) {
+	if items == nil || items.Kind != tree.SequenceNode {
+		return nil, fmt.Errorf("expected a list of %s items", elementType)
+	}
+
+	structs := tree.NewSequence()
+	for i, item := range items.Items {
+		structProperty, err := structJSON(elementType, guid, untagged(item))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		structs.Items = append(structs.Items, structProperty)
+	}
+
+	n := tree.NewMapping()
+	n.Append("Size", tree.NewScalar("0"))
+	n.Append("Count", tree.NewScalar(strconv.Itoa(len(structs.Items))))
+	n.Append("Items", structs)
+	n.Append("ElementType", tree.NewString(elementType))
+	n.Append("GUID", guid)
+	return n, nil
+}
+
+func requireKeys(n *tree.Node, keys ...string) error {
+	if n.Kind != tree.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping with %s", n.Line, strings.Join(keys, ", "))
+	}
+	for _, key := range keys {
+		if n.Get(key) == nil {
+			return fmt.Errorf("line %d: missing %s", n.Line, key)
+		}
+	}
+	return nil
+}
+
+// expandProperty is the counterpart of compactProperty. It returns the type
+// and JSON value of a property.
+func expandProperty(name string, n *tree.Node, types scalarTypes) (string, *tree.Node, error) {
+	tag := n.Tag
+
+	if name == "FowVisitedCoordinates" {
+		value, err := expandNode(untagged(n))
+		return tag, value, err
+	}
+
+	if tag == "" {
+		if n.Kind != tree.ScalarNode {
+			return "", nil, fmt.Errorf("line %d: a type tag is needed", n.Line)
+		}
+		switch n.Type() {
+		case "int":
+			return types.Int, n, nil
+		case "float":
+			return types.Float, n, nil
+		case "bool":
+			return types.Bool, n, nil
+		case "str":
+			return types.String, n, nil
+		}
+		return "", nil, fmt.Errorf("line %d: a type tag is needed for null", n.Line)
+	}
+
+	if !isPropertyType(tag) {
+		// a struct or an array of structs, named by the tag
+		if n.Kind == tree.SequenceNode {
+			value, err := structArrayJSON(tag, mustParseJSON(zeroGUID), n)
+			return "ArrayProperty", value, err
+		}
+		value, err := structJSON(tag, mustParseJSON(zeroGUID), untagged(n))
+		return "StructProperty", value, err
+	}
+
+	if n.Kind == tree.SequenceNode {
+		// an array of the tagged element type
+		items := tree.NewSequence()
+		for i, item := range n.Items {
+			var value *tree.Node
+			var err error
+			if tag == "EnumProperty" {
+				value, err = expandEnum(item)
+			} else {
+				value, err = expandNode(item)
+			}
+			if err != nil {
+				return "", nil, fmt.Errorf("%d: %w", i, err)
+			}
+			items.Items = append(items.Items, value)
+		}
+
+		array := tree.NewMapping()
+		array.Append("Count", tree.NewScalar(strconv.Itoa(len(items.Items))))
+		array.Append("Items", items)
+		array.Append("ElementType", tree.NewString(tag))
+		return "ArrayProperty", array, nil
+	}
+
+	switch {
+	case tag == "EnumProperty" || tag == "ByteProperty" && n.Kind == tree.ScalarNode && n.Type() == "str":
+		value, err := expandEnum(n)
+		return tag, value, err
+
+	case tag == "StructProperty":
+		err := requireKeys(n, "Name", "GUID", "Value")
+		if err != nil {
+			return "", nil, err
+		}
+		value, err := structJSON(n.Get("Name").Value, n.Get("GUID"), n.Get("Value"))
+		return tag, value, err
+
+	case tag == "ArrayProperty":
+		err := requireKeys(n, "ElementType", "GUID", "Items")
+		if err != nil {
+			return "", nil, err
+		}
+		value, err := structArrayJSON(n.Get("ElementType").Value, n.Get("GUID"), n.Get("Items"))
+		return tag, value, err
+
+	case variablesComponents[tag] && n.Kind == tree.MappingNode:
+		err := requireKeys(n, "Name", "Properties")
+		if err != nil {
+			return "", nil, err
+		}
+		properties, err := expandProperties(n.Get("Properties"), variablesTypes)
+		if err != nil {
+			return "", nil, err
+		}
+		variables := tree.NewMapping()
+		variables.Append("Name", n.Get("Name"))
+		variables.Append("Properties", properties)
+		return tag, variables, nil
+	}
+
+	value, err := expandNode(untagged(n))
+	return tag, value, err
+}
+
+func expandStructValue(structName string, n *tree.Node) (*tree.Node, error) {
+	if !leafStructs[structName] && n.Kind == tree.MappingNode {
+		return expandProperties(n, defaultTypes)
+	}
+	return expandNode(n)
+}
+
+// WriteYAMLDocument writes the YAML representation of an archive, see
+// docs/yaml-format.md.
+func WriteYAMLDocument(w io.Writer, archive *SaveArchive) error {
+	data, err := json.Marshal(NewJSONDocument(*archive))
+	if err != nil {
+		return err
+	}
+
+	document, err := tree.FromJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Write(w, compactNode(document), "Save written by revision, see docs/yaml-format.md.\nTurn it back into a save with revision import.")
+}
+
+// ReadYAMLDocument is the counterpart of WriteYAMLDocument.
+func ReadYAMLDocument(r io.Reader) (SaveArchive, error) {
+	document, err := yaml.Parse(r)
+	if err != nil {
+		return SaveArchive{}, err
+	}
+
+	document, err = expandNode(document)
+	if err != nil {
+		return SaveArchive{}, err
+	}
+
+	data, err := document.JSON()
+	if err != nil {
+		return SaveArchive{}, err
+	}
+
+	return ReadJSONDocument(bytes.NewReader(data))
+}
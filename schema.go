@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"revision-go/remnant/schema"
+)
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	format := flags.String("format", "jsonschema", "output format: jsonschema, go or schema (a description in JSON, read by revision codegen)")
+	pkg := flags.String("package", "saves", "package name of the Go output")
+	output := flags.String("o", "", "output file (default: standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision schema [-format jsonschema|go|schema] [-package saves] [-o file] dir|file.sav...")
+		fmt.Fprintln(flags.Output(), "Directories are searched recursively for .sav files.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one directory or save file")
+	}
+
+	var write func(w io.Writer, s *schema.Schema) error
+	switch *format {
+	case "jsonschema":
+		write = func(w io.Writer, s *schema.Schema) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(s.JSONSchema())
+		}
+	case "go":
+		write = func(w io.Writer, s *schema.Schema) error {
+			return s.WriteGoStubs(w, *pkg)
+		}
+	case "schema":
+		write = func(w io.Writer, s *schema.Schema) error {
+			return s.Write(w)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	saves, err := findSaves(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	inferred := schema.New()
+	for _, save := range saves {
+		log.SetOutput(io.Discard)
+		archive, err := loadSave(save)
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			log.Printf("skipping %s", err)
+			continue
+		}
+		inferred.Add(&archive)
+	}
+	if inferred.Saves == 0 {
+		return fmt.Errorf("no save files could be parsed")
+	}
+
+	if *output == "" {
+		return write(os.Stdout, inferred)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = write(file, inferred)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"revision-go/server"
+	"time"
+)
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	maxSize := flags.Int64("max-size", 64<<20, "largest accepted request body, in bytes")
+	timeout := flags.Duration("timeout", 30*time.Second, "time limit for handling a request")
+	maxSaves := flags.Int("max-saves", 32, "number of uploaded saves kept in memory")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision serve [-addr localhost:8080] [-max-size bytes] [-timeout 30s] [-max-saves 32]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments")
+	}
+	if *maxSaves < 1 {
+		return fmt.Errorf("-max-saves must be at least 1")
+	}
+
+	httpServer := &http.Server{
+		Addr: *addr,
+		Handler: server.New(server.Options{
+			MaxRequestSize: *maxSize,
+			RequestTimeout: *timeout,
+			MaxSaves:       *maxSaves,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+		// reading the body and writing the response count against the
+		// request timeout as well, with some room for slow connections
+		ReadTimeout:  *timeout + 10*time.Second,
+		WriteTimeout: *timeout + 10*time.Second,
+	}
+
+	log.Printf("listening on http://%s", *addr)
+	return httpServer.ListenAndServe()
+}
@@ -0,0 +1,379 @@
+// Package server exposes the parser over a local HTTP JSON API.
+//
+//	POST /saves                      upload a .sav, returns its ID
+//	GET  /saves                      list uploaded saves
+//	GET  /saves/{id}                 parsed archive, in the JSON dump format
+//	GET  /saves/{id}/lookup?path=... node at a property path
+//	GET  /saves/{id}/characters      characters, inventories and builds of a profile
+//	GET  /saves/{id}/world           campaign report of a world save
+//	POST /saves/{id}/patch           apply a patch, returns the ID of the result
+//	GET  /saves/{id}/download        encoded .sav
+//	GET  /diff?old={id}&new={id}     changes between two saves
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"revision-go/remnant"
+	"revision-go/remnant/profile"
+	"revision-go/remnant/world"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// largest accepted request body, in bytes
+	MaxRequestSize int64
+	// time limit for handling a single request
+	RequestTimeout time.Duration
+	// number of saves kept in memory, the oldest are dropped first
+	MaxSaves int
+}
+
+type storedSave struct {
+	ID       string
+	Uploaded time.Time
+	Size     int
+	// encoded save as uploaded, decoded again for patches so that the
+	// stored archive is never modified
+	data    []byte
+	archive remnant.SaveArchive
+}
+
+type server struct {
+	options Options
+
+	mutex sync.RWMutex
+	saves map[string]*storedSave
+	order []string
+}
+
+// statusError is an error with the HTTP status to report it with.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func errorStatus(status int, format string, args ...interface{}) error {
+	return &statusError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// New returns the API handler.
+func New(options Options) http.Handler {
+	s := &server{
+		options: options,
+		saves:   map[string]*storedSave{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/saves", s.handler(s.handleSaves))
+	mux.Handle("/saves/", s.handler(s.handleSave))
+	mux.Handle("/diff", s.handler(s.handleDiff))
+
+	return http.TimeoutHandler(mux, options.RequestTimeout, `{"Error":"request timed out"}`)
+}
+
+func (s *server) handler(handle func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.options.MaxRequestSize)
+
+		err := callHandler(handle, w, r)
+		if err == nil {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		var requestError *statusError
+		var sizeError *http.MaxBytesError
+		switch {
+		case errors.As(err, &requestError):
+			status = requestError.status
+		case errors.As(err, &sizeError):
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		writeJSON(w, status, struct{ Error string }{err.Error()})
+	})
+}
+
+// callHandler runs handle, turning a panic into an error so that a save the
+// parser cannot cope with does not take the server down.
+func callHandler(handle func(w http.ResponseWriter, r *http.Request) error, w http.ResponseWriter, r *http.Request) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("internal error: %v", recovered)
+		}
+	}()
+
+	return handle(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(value)
+}
+
+func allowMethod(r *http.Request, method string) error {
+	if r.Method != method {
+		return errorStatus(http.StatusMethodNotAllowed, "method %s not allowed, expected %s", r.Method, method)
+	}
+	return nil
+}
+
+func decodeSave(data []byte) (remnant.SaveArchive, error) {
+	archive, err := remnant.DecodeSave(data)
+	if err != nil {
+		return remnant.SaveArchive{}, errorStatus(http.StatusUnprocessableEntity, "%w", err)
+	}
+
+	return archive, nil
+}
+
+func (s *server) store(data []byte, archive remnant.SaveArchive) *storedSave {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:8])
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if save, ok := s.saves[id]; ok {
+		return save
+	}
+
+	save := &storedSave{
+		ID:       id,
+		Uploaded: time.Now().UTC(),
+		Size:     len(data),
+		data:     data,
+		archive:  archive,
+	}
+	s.saves[id] = save
+	s.order = append(s.order, id)
+
+	for len(s.order) > s.options.MaxSaves {
+		delete(s.saves, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	return save
+}
+
+func (s *server) save(id string) (*storedSave, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	save, ok := s.saves[id]
+	if !ok {
+		return nil, errorStatus(http.StatusNotFound, "save %s not found", id)
+	}
+	return save, nil
+}
+
+// readUpload returns the uploaded file of a multipart form, or the raw body.
+func readUpload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return io.ReadAll(r.Body)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errorStatus(http.StatusBadRequest, "missing file field: %w", err)
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func (s *server) handleSaves(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.RLock()
+		saves := make([]*storedSave, 0, len(s.order))
+		for _, id := range s.order {
+			saves = append(saves, s.saves[id])
+		}
+		s.mutex.RUnlock()
+
+		return writeJSON(w, http.StatusOK, saves)
+
+	case http.MethodPost:
+		data, err := readUpload(r)
+		if err != nil {
+			return err
+		}
+
+		archive, err := decodeSave(data)
+		if err != nil {
+			return err
+		}
+
+		save := s.store(data, archive)
+		w.Header().Set("Location", "/saves/"+save.ID)
+		return writeJSON(w, http.StatusCreated, save)
+	}
+
+	return errorStatus(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+}
+
+func (s *server) handleSave(w http.ResponseWriter, r *http.Request) error {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/saves/"), "/")
+	if len(parts) > 2 {
+		return errorStatus(http.StatusNotFound, "not found")
+	}
+
+	save, err := s.save(parts[0])
+	if err != nil {
+		return err
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch action {
+	case "":
+		err = allowMethod(r, http.MethodGet)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, http.StatusOK, remnant.NewJSONDocument(save.archive))
+
+	case "lookup":
+		return s.handleLookup(w, r, save)
+
+	case "characters":
+		err = allowMethod(r, http.MethodGet)
+		if err != nil {
+			return err
+		}
+		characters, err := profile.ReadCharacters(&save.archive)
+		if err != nil {
+			return errorStatus(http.StatusUnprocessableEntity, "%w", err)
+		}
+		return writeJSON(w, http.StatusOK, characters)
+
+	case "world":
+		err = allowMethod(r, http.MethodGet)
+		if err != nil {
+			return err
+		}
+		reports, err := world.ReadReports(&save.archive)
+		if err != nil {
+			return errorStatus(http.StatusUnprocessableEntity, "%w", err)
+		}
+		return writeJSON(w, http.StatusOK, reports)
+
+	case "patch":
+		return s.handlePatch(w, r, save)
+
+	case "download":
+		err = allowMethod(r, http.MethodGet)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.sav"`, save.ID))
+		_, err = w.Write(save.data)
+		return err
+	}
+
+	return errorStatus(http.StatusNotFound, "not found")
+}
+
+func (s *server) handleLookup(w http.ResponseWriter, r *http.Request, save *storedSave) error {
+	err := allowMethod(r, http.MethodGet)
+	if err != nil {
+		return err
+	}
+
+	path, err := remnant.ParsePath(r.URL.Query().Get("path"))
+	if err != nil {
+		return errorStatus(http.StatusBadRequest, "%w", err)
+	}
+
+	node, err := remnant.Lookup(&save.archive, path)
+	if err != nil {
+		return errorStatus(http.StatusNotFound, "%w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, node)
+}
+
+func (s *server) handlePatch(w http.ResponseWriter, r *http.Request, save *storedSave) error {
+	err := allowMethod(r, http.MethodPost)
+	if err != nil {
+		return err
+	}
+
+	operations, err := remnant.ReadPatch(r.Body)
+	if err != nil {
+		return errorStatus(http.StatusBadRequest, "invalid patch: %w", err)
+	}
+
+	archive, err := decodeSave(save.data)
+	if err != nil {
+		return err
+	}
+
+	err = remnant.ApplyPatch(&archive, operations)
+	if err != nil {
+		return errorStatus(http.StatusConflict, "%w", err)
+	}
+
+	data, err := remnant.EncodeSave(&archive)
+	if err != nil {
+		return errorStatus(http.StatusUnprocessableEntity, "%w", err)
+	}
+
+	// parsed again so that the stored archive matches the encoded save
+	patched, err := decodeSave(data)
+	if err != nil {
+		return err
+	}
+
+	result := s.store(data, patched)
+	w.Header().Set("Location", "/saves/"+result.ID)
+	return writeJSON(w, http.StatusCreated, result)
+}
+
+func (s *server) handleDiff(w http.ResponseWriter, r *http.Request) error {
+	err := allowMethod(r, http.MethodGet)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	oldSave, err := s.save(query.Get("old"))
+	if err != nil {
+		return err
+	}
+
+	newSave, err := s.save(query.Get("new"))
+	if err != nil {
+		return err
+	}
+
+	changes := remnant.Diff(&oldSave.archive, &newSave.archive)
+	if changes == nil {
+		changes = []remnant.Change{}
+	}
+
+	return writeJSON(w, http.StatusOK, changes)
+}
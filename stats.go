@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant"
+	"text/tabwriter"
+)
+
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the statistics as JSON")
+	top := flags.Int("top", 10, "largest properties listed, 0 for all")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision stats [-json] [-top 10] save.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one save file")
+	}
+
+	if *top < 0 {
+		return fmt.Errorf("-top must not be negative")
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	stats := remnant.NewStats(&archive, *top)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "archives\t%d\n", stats.Archives)
+	fmt.Fprintf(w, "objects\t%d\n", stats.Objects)
+	fmt.Fprintf(w, "components\t%d\n", stats.Components)
+	fmt.Fprintf(w, "properties\t%d\n", stats.Properties)
+	fmt.Fprintf(w, "actors\t%d, %d dynamic, %d destroyed\n", stats.Actors, stats.DynamicActors, stats.Destroyed)
+	fmt.Fprintf(w, "names\t%d, %d duplicated\n", stats.Names, len(stats.DuplicateNames))
+	fmt.Fprintf(w, "deepest nesting\t%d at %s\n", stats.MaxDepth, stats.DeepestPath)
+
+	fmt.Fprint(w, "\nProperty types\nTYPE\tCOUNT\n")
+	for _, count := range stats.PropertyTypes {
+		fmt.Fprintf(w, "%s\t%d\n", count.Type, count.Count)
+	}
+
+	fmt.Fprint(w, "\nLargest properties\nSIZE\tTYPE\tPATH\n")
+	for _, property := range stats.LargestProperties {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", property.Size, property.Type, property.Path)
+	}
+
+	if len(stats.Containers) > 0 {
+		fmt.Fprint(w, "\nPersistence containers\nACTORS\tDYNAMIC\tDESTROYED\tPATH\n")
+		for _, container := range stats.Containers {
+			fmt.Fprintf(w, "%d\t%d\t%d\t%s\n", container.Actors, container.DynamicActors, container.Destroyed, container.Path)
+		}
+	}
+
+	if len(stats.DuplicateNames) > 0 {
+		fmt.Fprint(w, "\nDuplicate names\nCOUNT\tNAME\tARCHIVE\n")
+		for _, name := range stats.DuplicateNames {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", name.Count, name.Name, name.Path)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,23 @@
+package ue
+
+import "strings"
+
+// AssetName returns the last element of an asset path without the object
+// name, e.g. Trait_Vigor for /Game/.../Trait_Vigor.Trait_Vigor_C.
+func AssetName(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// ObjectName returns the last element of a class or object path, e.g.
+// Quest_Boss_C for /Game/World_Nerud/Quests/Quest_Boss.Quest_Boss_C or
+// ZoneActor_1 for /Game/World_Nerud/Maps/Nerud:PersistentLevel.ZoneActor_1.
+func ObjectName(path string) string {
+	if i := strings.LastIndexAny(path, "/.:"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -3,6 +3,7 @@ package ue
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 	"revision-go/memory"
 )
@@ -20,8 +21,7 @@ func ReadFString(r io.Reader) (string, error) {
 	if stringSize <= 0 {
 		return "", nil
 	}
-	stringData := make([]byte, stringSize)
-	err = binary.Read(r, binary.LittleEndian, &stringData)
+	stringData, err := memory.ReadBytes(r, int64(stringSize))
 	if err != nil {
 		return "", err
 	}
@@ -156,3 +156,64 @@ func ReadFTopLevelAssetPath(r io.Reader) (FTopLevelAssetPath, error) {
 
 	return topLevelAssetPath, nil
 }
+
+func WriteFString(w io.Writer, value string) error {
+	if value == "" {
+		return memory.WriteInt[int32](w, 0)
+	}
+
+	err := memory.WriteInt[int32](w, int32(len(value)+1))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append([]byte(value), 0))
+	return err
+}
+
+func WriteFName(w io.Writer, name FName) error {
+	const HAS_NUMBER = 1 << 15
+
+	if name.Index&HAS_NUMBER != 0 {
+		return fmt.Errorf("name index is too large: %d", name.Index)
+	}
+
+	if name.Number != 0 {
+		err := memory.WriteInt[uint16](w, name.Index|HAS_NUMBER)
+		if err != nil {
+			return err
+		}
+		return memory.WriteInt[int32](w, name.Number)
+	}
+
+	return memory.WriteInt[uint16](w, name.Index)
+}
+
+func WriteGuid(w io.Writer, guid FGuid) error {
+	return binary.Write(w, binary.LittleEndian, guid)
+}
+
+func WriteFInfo(w io.Writer, info FInfo) error {
+	return binary.Write(w, binary.LittleEndian, info)
+}
+
+func WriteFVector(w io.Writer, vector FVector) error {
+	return binary.Write(w, binary.LittleEndian, vector)
+}
+
+func WriteFQuaternion(w io.Writer, quaternion FQuaternion) error {
+	return binary.Write(w, binary.LittleEndian, quaternion)
+}
+
+func WriteFTransform(w io.Writer, transform FTransform) error {
+	return binary.Write(w, binary.LittleEndian, transform)
+}
+
+func WriteFTopLevelAssetPath(w io.Writer, topLevelAssetPath FTopLevelAssetPath) error {
+	err := WriteFString(w, topLevelAssetPath.Path)
+	if err != nil {
+		return err
+	}
+
+	return WriteFString(w, topLevelAssetPath.Name)
+}
@@ -16,13 +16,36 @@ func createIfNotExist(name string) error {
 	return err
 }
 
-func saveJSON(foldername string, name string, data []byte) error {
+// CreateJSONFile creates json/foldername/name.json, along with its
+// directories, for writing a large JSON document without building it in
+// memory.
+func CreateJSONFile(foldername string, name string) (*os.File, error) {
+	err := createIfNotExist("json")
+	if err != nil {
+		return nil, err
+	}
 	combinedPath := path.Join("json", foldername)
-	err := createIfNotExist(combinedPath)
+	err = createIfNotExist(combinedPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(path.Join(combinedPath, name+".json"))
+}
+
+func saveJSON(foldername string, name string, data interface{}) error {
+	file, err := CreateJSONFile(foldername, name)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path.Join(combinedPath, name+".json"), data, 0644)
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 func saveBinary(foldername string, name string, data []byte) error {
@@ -37,15 +60,7 @@ func saveBinary(foldername string, name string, data []byte) error {
 func SaveToFile(foldername string, name string, dataType string, data interface{}) error {
 	switch dataType {
 	case "json":
-		err := createIfNotExist("json")
-		if err != nil {
-			return err
-		}
-		jsonObject, err := json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			return err
-		}
-		return saveJSON(foldername, name, jsonObject)
+		return saveJSON(foldername, name, data)
 	case "bin":
 		if config.DEBUG_SAVE_BINARY {
 			err := createIfNotExist("binary")
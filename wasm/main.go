@@ -0,0 +1,92 @@
+//go:build js && wasm
+
+// Command wasm is the WebAssembly build of the parser, so that saves can be
+// read and written in the browser without uploading them anywhere. It sets a
+// global revision object with two functions:
+//
+//	revision.parseSave(Uint8Array) -> string       save file to JSON dump
+//	revision.writeSave(string)     -> Uint8Array   JSON dump to save file
+//
+// Both return an Error instead of throwing; revision.js wraps them.
+//
+//	GOOS=js GOARCH=wasm go build -o revision.wasm ./wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"revision-go/remnant"
+	"strings"
+	"syscall/js"
+)
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+func parseSave(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || !args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+		return jsError(fmt.Errorf("parseSave expects a Uint8Array"))
+	}
+
+	data := make([]byte, args[0].Length())
+	js.CopyBytesToGo(data, args[0])
+
+	archive, err := remnant.DecodeSave(data)
+	if err != nil {
+		return jsError(err)
+	}
+
+	document, err := json.Marshal(remnant.NewJSONDocument(archive))
+	if err != nil {
+		return jsError(err)
+	}
+
+	return string(document)
+}
+
+func writeSave(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return jsError(fmt.Errorf("writeSave expects a JSON string"))
+	}
+
+	archive, err := remnant.ReadJSONDocument(strings.NewReader(args[0].String()))
+	if err != nil {
+		return jsError(fmt.Errorf("invalid JSON document: %w", err))
+	}
+
+	data, err := remnant.EncodeSave(&archive)
+	if err != nil {
+		return jsError(err)
+	}
+
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return array
+}
+
+// guard returns fn as a JavaScript function that returns an Error for a
+// panic, which would otherwise end the instance. Running out of memory cannot
+// be recovered from; DecodeSave rejects saves that claim more data than they
+// hold instead.
+func guard(fn func(this js.Value, args []js.Value) interface{}) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (result interface{}) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				result = jsError(fmt.Errorf("internal error: %v", recovered))
+			}
+		}()
+
+		return fn(this, args)
+	})
+}
+
+func main() {
+	js.Global().Set("revision", js.ValueOf(map[string]interface{}{
+		"parseSave": guard(parseSave),
+		"writeSave": guard(writeSave),
+	}))
+
+	// the functions are only callable while the program runs
+	select {}
+}
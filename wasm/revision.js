@@ -0,0 +1,25 @@
+// Loads revision.wasm and returns its parseSave and writeSave functions.
+// wasm_exec.js from the Go distribution has to be loaded first.
+//
+// The JSON is returned and accepted as a string: 64-bit integers in saves do
+// not survive JSON.parse, so edit it with a parser that keeps them intact.
+export async function loadRevision(url = "revision.wasm") {
+  const go = new Go();
+  const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+  go.run(instance);
+
+  const { parseSave, writeSave } = globalThis.revision;
+  const check = (result) => {
+    if (result instanceof Error) {
+      throw result;
+    }
+    return result;
+  };
+
+  return {
+    // Uint8Array with the contents of a .sav file -> JSON string
+    parseSave: (data) => check(parseSave(data)),
+    // JSON string -> Uint8Array with the contents of a .sav file
+    writeSave: (json) => check(writeSave(json)),
+  };
+}
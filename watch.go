@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"revision-go/names"
+	"revision-go/remnant"
+	"revision-go/remnant/profile"
+	"revision-go/remnant/world"
+	"sort"
+	"time"
+)
+
+// watchEvent is a line of watch output. Change is set for raw changes, the
+// other kinds summarize them.
+type watchEvent struct {
+	Time    time.Time
+	File    string
+	Kind    string
+	Message string
+	Change  *remnant.Change
+}
+
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+// parse attempts on an unchanged file before it is reported as unreadable
+const maxWatchFailures = 3
+
+type watchedFile struct {
+	parsed  fileStat
+	pending fileStat
+	archive *remnant.SaveArchive
+
+	// failed parse attempts on the file as it is at failed; the file is not
+	// parsed again after maxWatchFailures until it changes
+	failed   fileStat
+	failures int
+}
+
+type watcher struct {
+	dir          string
+	files        map[string]*watchedFile
+	changes      bool
+	displayNames *names.Database
+	emit         func(event watchEvent) error
+}
+
+func statSaves(dir string) (map[string]fileStat, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sav"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]fileStat{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stats[filepath.Base(path)] = fileStat{size: info.Size(), modTime: info.ModTime()}
+	}
+	return stats, nil
+}
+
+// poll parses the files whose size or mtime changed and then stayed the same
+// for one interval, which is taken as the game having finished writing, and
+// reports files that were removed.
+func (w *watcher) poll(baseline bool) error {
+	stats, err := statSaves(w.dir)
+	if err != nil {
+		return err
+	}
+
+	saveNames := make([]string, 0, len(stats))
+	for name := range stats {
+		saveNames = append(saveNames, name)
+	}
+	sort.Strings(saveNames)
+
+	for _, name := range saveNames {
+		stat := stats[name]
+
+		file, ok := w.files[name]
+		if !ok {
+			file = &watchedFile{}
+			w.files[name] = file
+		}
+		if file.archive != nil && stat == file.parsed {
+			continue
+		}
+		if file.failures >= maxWatchFailures && stat == file.failed {
+			continue
+		}
+		if !baseline && stat != file.pending {
+			file.pending = stat
+			continue
+		}
+
+		archive, err := loadSave(filepath.Join(w.dir, name))
+		if err != nil {
+			// most likely still being written, retried on the next poll
+			file.pending = fileStat{}
+			if stat != file.failed {
+				file.failed = stat
+				file.failures = 0
+			}
+			file.failures++
+			if file.failures == maxWatchFailures {
+				err = w.emitMessage(name, "error", err.Error())
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		file.failures = 0
+
+		if file.archive == nil && !baseline {
+			err = w.emitMessage(name, "file", "new save")
+		} else if file.archive != nil {
+			err = w.compare(name, file.archive, &archive)
+		}
+		if err != nil {
+			return err
+		}
+
+		file.archive = &archive
+		file.parsed = stat
+	}
+
+	removed := []string{}
+	for name := range w.files {
+		if _, ok := stats[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		delete(w.files, name)
+		err = w.emitMessage(name, "file", "save removed")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *watcher) emitMessage(name string, kind string, message string) error {
+	return w.emit(watchEvent{Time: time.Now(), File: name, Kind: kind, Message: message})
+}
+
+func (w *watcher) compare(name string, oldArchive *remnant.SaveArchive, newArchive *remnant.SaveArchive) error {
+	for _, message := range inventoryChanges(oldArchive, newArchive, w.displayNames) {
+		err := w.emitMessage(name, "item", message)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, message := range bossChanges(oldArchive, newArchive, w.displayNames) {
+		err := w.emitMessage(name, "boss", message)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !w.changes {
+		return nil
+	}
+
+	for _, change := range remnant.Diff(oldArchive, newArchive) {
+		change := change
+		err := w.emit(watchEvent{Time: time.Now(), File: name, Kind: "change", Change: &change})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// itemQuantities sums item quantities by character slot and blueprint path.
+func itemQuantities(archive *remnant.SaveArchive) map[int]map[string]int32 {
+	characters, err := profile.ReadCharacters(archive)
+	if err != nil {
+		return nil
+	}
+
+	quantities := map[int]map[string]int32{}
+	for _, character := range characters {
+		items := map[string]int32{}
+		for _, item := range character.Items {
+			items[item.BlueprintPath] += item.Quantity
+		}
+		quantities[character.Index] = items
+	}
+	return quantities
+}
+
+func inventoryChanges(oldArchive *remnant.SaveArchive, newArchive *remnant.SaveArchive, displayNames *names.Database) []string {
+	oldQuantities := itemQuantities(oldArchive)
+	newQuantities := itemQuantities(newArchive)
+
+	messages := []string{}
+	for index, items := range newQuantities {
+		for blueprintPath, quantity := range items {
+			if difference := quantity - oldQuantities[index][blueprintPath]; difference > 0 {
+				messages = append(messages, fmt.Sprintf("character %d gained %d x %s", index, difference, displayName(displayNames, blueprintPath)))
+			}
+		}
+	}
+	for index, items := range oldQuantities {
+		for blueprintPath, quantity := range items {
+			if difference := quantity - newQuantities[index][blueprintPath]; difference > 0 {
+				messages = append(messages, fmt.Sprintf("character %d lost %d x %s", index, difference, displayName(displayNames, blueprintPath)))
+			}
+		}
+	}
+
+	sort.Strings(messages)
+	return messages
+}
+
+func defeatedBosses(archive *remnant.SaveArchive) map[string]bool {
+	reports, err := world.ReadReports(archive)
+	if err != nil {
+		return nil
+	}
+
+	bosses := map[string]bool{}
+	for _, report := range reports {
+		for _, boss := range report.BossesDefeated {
+			bosses[boss] = true
+		}
+	}
+	return bosses
+}
+
+func bossChanges(oldArchive *remnant.SaveArchive, newArchive *remnant.SaveArchive, displayNames *names.Database) []string {
+	oldBosses := defeatedBosses(oldArchive)
+
+	messages := []string{}
+	for boss := range defeatedBosses(newArchive) {
+		if !oldBosses[boss] {
+			messages = append(messages, "boss defeated: "+displayNames.DisplayName(boss, boss))
+		}
+	}
+
+	sort.Strings(messages)
+	return messages
+}
+
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := flags.Duration("interval", 2*time.Second, "polling interval")
+	asJSON := flags.Bool("json", false, "print events as JSON lines")
+	output := flags.String("o", "", "append events as JSON lines to this file instead of printing them")
+	changes := flags.Bool("changes", true, "also report every changed value, not only item and boss events")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision watch [-interval 2s] [-json] [-o events.jsonl] [-changes=false] [-names] dir")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a save directory")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	w := &watcher{
+		dir:          flags.Arg(0),
+		files:        map[string]*watchedFile{},
+		changes:      *changes,
+		displayNames: displayNames,
+		emit: func(event watchEvent) error {
+			if *asJSON || *output != "" {
+				return encoder.Encode(event)
+			}
+			return printWatchEvent(event, displayNames)
+		},
+	}
+
+	err = w.poll(true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "watching %s, %d saves\n", w.dir, len(w.files))
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+			err = w.poll(false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func printWatchEvent(event watchEvent, displayNames *names.Database) error {
+	prefix := fmt.Sprintf("%s %s", event.Time.Format("15:04:05"), event.File)
+	if event.Change != nil {
+		fmt.Printf("%s ", prefix)
+		return printChange(*event.Change, displayNames)
+	}
+
+	_, err := fmt.Printf("%s %s: %s\n", prefix, event.Kind, event.Message)
+	return err
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/names"
+	"revision-go/remnant/world"
+)
+
+func runWorld(args []string) error {
+	flags := flag.NewFlagSet("world", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	nameFlags := addNameFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision world [-json] [-names] save.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one world save")
+	}
+
+	displayNames, err := nameFlags.database()
+	if err != nil {
+		return err
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	reports, err := world.ReadReports(&archive)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	for _, report := range reports {
+		printWorldReport(&report, displayNames)
+	}
+
+	return nil
+}
+
+func printWorldReport(report *world.Report, displayNames *names.Database) {
+	mode := report.Mode
+	if mode == "" {
+		mode = "Container"
+	}
+	fmt.Printf("%s %s\n", mode, report.Path)
+	fmt.Printf("  World: %s\n", displayNames.DisplayName(report.World, report.World))
+	if report.Checkpoint != "" {
+		fmt.Printf("  Checkpoint: %s\n", displayNames.DisplayName(report.Checkpoint, report.Checkpoint))
+	}
+
+	for _, zone := range report.Zones {
+		fmt.Printf("  Zone: %s (%s)\n", displayNames.DisplayName(zone.Name, zone.Name), displayNames.DisplayName(zone.World, zone.World))
+	}
+	for _, dungeon := range report.Dungeons {
+		fmt.Printf("  Dungeon: %s (%s)\n", displayNames.DisplayName(dungeon.Name, dungeon.Name), displayNames.DisplayName(dungeon.World, dungeon.World))
+	}
+	for _, event := range report.Events {
+		fmt.Printf("  Event: %s%s\n", displayNames.DisplayName(event.ClassPath, event.Name), questStatus(&event))
+	}
+	for _, quest := range report.Quests {
+		fmt.Printf("  Quest: %s%s\n", displayNames.DisplayName(quest.ClassPath, quest.Name), questStatus(&quest))
+	}
+	for _, boss := range report.BossesDefeated {
+		fmt.Printf("  Boss defeated: %s\n", displayNames.DisplayName(boss, boss))
+	}
+}
+
+func questStatus(quest *world.Quest) string {
+	switch {
+	case quest.Completed:
+		return " [completed]"
+	case quest.State != "":
+		return " [" + quest.State + "]"
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"revision-go/remnant"
+)
+
+func runYAML(args []string) error {
+	flags := flag.NewFlagSet("yaml", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: revision yaml [-o file.yaml] save.sav")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one save file")
+	}
+
+	archive, err := loadSave(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return remnant.WriteYAMLDocument(os.Stdout, &archive)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = remnant.WriteYAMLDocument(file, &archive)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,518 @@
+// Package yaml reads and writes the subset of YAML used for human-editable
+// saves: block and flow mappings and sequences, plain and quoted scalars,
+// comments and local tags such as !IntProperty. Anchors, aliases, block
+// scalars and multi-line scalars are not supported. Documents are trees of
+// tree.Node.
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"revision-go/internal/tree"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type line struct {
+	number int
+	indent int
+	text   string
+}
+
+type parser struct {
+	lines []line
+	pos   int
+}
+
+func errorAt(number int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", number, fmt.Sprintf(format, args...))
+}
+
+// snippet shortens text quoted in errors.
+func snippet(text string) string {
+	if len(text) > 40 {
+		return text[:40] + "..."
+	}
+	return text
+}
+
+// tokenStart reports whether a quote at i starts a quoted scalar rather than
+// being part of a plain one.
+func tokenStart(text string, i int) bool {
+	return i == 0 || strings.ContainsRune(" [{,:", rune(text[i-1]))
+}
+
+// stripComment removes a comment from the end of a line.
+func stripComment(text string) string {
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+			} else {
+				quote = 0
+			}
+		case quote == 0 && (c == '"' || c == '\'') && tokenStart(text, i):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func splitLines(data string) ([]line, error) {
+	lines := []line{}
+	for i, text := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		number := i + 1
+		if i == 0 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		content := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, errorAt(number, "tabs cannot be used for indentation")
+		}
+		indent := len(text) - len(content)
+
+		content = strings.TrimRight(stripComment(content), " \t")
+		if content == "" {
+			continue
+		}
+		if indent == 0 && (content == "---" || content == "..." || strings.HasPrefix(content, "%")) {
+			continue
+		}
+
+		lines = append(lines, line{number: number, indent: indent, text: content})
+	}
+	return lines, nil
+}
+
+// Parse reads a YAML document.
+func Parse(r io.Reader) (*tree.Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("document is not valid UTF-8")
+	}
+
+	lines, err := splitLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return &tree.Node{Kind: tree.ScalarNode}, nil
+	}
+
+	p := &parser{lines: lines}
+	if lines[0].indent != 0 {
+		return nil, errorAt(lines[0].number, "unexpected indentation")
+	}
+
+	n, err := p.block(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, errorAt(p.lines[p.pos].number, "unexpected %q", snippet(p.lines[p.pos].text))
+	}
+	return n, nil
+}
+
+func isSequenceEntry(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits a "key: value" line.
+func splitKey(text string, number int) (string, string, bool, error) {
+	if text[0] == '"' || text[0] == '\'' {
+		f := &flowParser{s: text, line: number}
+		key, err := f.quoted()
+		if err != nil {
+			return "", "", false, err
+		}
+		rest := strings.TrimLeft(text[f.i:], " ")
+		if rest == ":" || strings.HasPrefix(rest, ": ") {
+			return key, strings.TrimLeft(rest[1:], " "), true, nil
+		}
+		return "", "", false, nil
+	}
+
+	if strings.ContainsRune("[{!&*|>", rune(text[0])) {
+		return "", "", false, nil
+	}
+
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false, nil
+		}
+		i = len(text) - 1
+	}
+	return strings.TrimRight(text[:i], " "), strings.TrimLeft(text[i+1:], " "), true, nil
+}
+
+func (p *parser) isKey(l line) bool {
+	_, _, ok, _ := splitKey(l.text, l.number)
+	return ok
+}
+
+// block reads the node starting at the current line.
+func (p *parser) block(indent int) (*tree.Node, error) {
+	l := p.lines[p.pos]
+	if isSequenceEntry(l.text) {
+		return p.sequence(indent)
+	}
+	if p.isKey(l) {
+		return p.mapping(indent)
+	}
+
+	p.pos++
+	return p.inline(l.text, l.number)
+}
+
+func (p *parser) mapping(indent int) (*tree.Node, error) {
+	n := tree.NewMapping()
+	n.Line = p.lines[p.pos].number
+
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		l := p.lines[p.pos]
+		key, rest, ok, err := splitKey(l.text, l.number)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errorAt(l.number, "expected \"key: value\", got %q", snippet(l.text))
+		}
+		p.pos++
+
+		value, err := p.value(rest, indent, l.number, true)
+		if err != nil {
+			return nil, err
+		}
+		n.Append(key, value)
+	}
+
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, errorAt(p.lines[p.pos].number, "unexpected indentation")
+	}
+	return n, nil
+}
+
+func (p *parser) sequence(indent int) (*tree.Node, error) {
+	n := tree.NewSequence()
+	n.Line = p.lines[p.pos].number
+
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSequenceEntry(p.lines[p.pos].text) {
+		l := p.lines[p.pos]
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		var item *tree.Node
+		var err error
+		if rest != "" && (isSequenceEntry(rest) || p.isKey(line{l.number, 0, rest})) {
+			// a collection starting on the line of the dash continues at the
+			// column it starts at
+			column := l.indent + len(l.text) - len(rest)
+			p.lines[p.pos] = line{number: l.number, indent: column, text: rest}
+			item, err = p.block(column)
+		} else {
+			p.pos++
+			item, err = p.value(rest, indent, l.number, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.Items = append(n.Items, item)
+	}
+
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, errorAt(p.lines[p.pos].number, "unexpected indentation")
+	}
+	return n, nil
+}
+
+// value reads what follows "key:" or "-": a node on the same line, or a
+// block on the following lines. The value of a mapping key may be a sequence
+// at the indentation of the key.
+func (p *parser) value(rest string, indent int, number int, inMapping bool) (*tree.Node, error) {
+	tag := ""
+	if strings.HasPrefix(rest, "!") {
+		end := strings.IndexByte(rest, ' ')
+		if end < 0 {
+			tag, rest = rest[1:], ""
+		}
+	}
+	if rest != "" {
+		return p.inline(rest, number)
+	}
+
+	if p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		if next.indent > indent || inMapping && next.indent == indent && isSequenceEntry(next.text) {
+			n, err := p.block(next.indent)
+			if err != nil {
+				return nil, err
+			}
+			n.Tag = tag
+			return n, nil
+		}
+	}
+
+	return &tree.Node{Kind: tree.ScalarNode, Tag: tag, Line: number}, nil
+}
+
+// inline reads a node written on one line, or a flow collection spanning
+// several.
+func (p *parser) inline(text string, number int) (*tree.Node, error) {
+	for flowDepth(text) > 0 && p.pos < len(p.lines) {
+		text += " " + p.lines[p.pos].text
+		p.pos++
+	}
+
+	f := &flowParser{s: text, line: number}
+	n, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpaces()
+	if f.i < len(f.s) {
+		return nil, errorAt(number, "unexpected %q", snippet(f.s[f.i:]))
+	}
+	return n, nil
+}
+
+// flowDepth returns the number of flow collections left open at the end of
+// text.
+func flowDepth(text string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && tokenStart(text, i):
+			quote = c
+		case quote == 0 && (c == '[' || c == '{'):
+			depth++
+		case quote == 0 && (c == ']' || c == '}'):
+			depth--
+		}
+	}
+	return depth
+}
+
+type flowParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (f *flowParser) errorf(format string, args ...interface{}) error {
+	return errorAt(f.line, format, args...)
+}
+
+func (f *flowParser) skipSpaces() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *flowParser) atEnd(inFlow bool) bool {
+	return f.i >= len(f.s) || inFlow && strings.ContainsRune(",]}", rune(f.s[f.i]))
+}
+
+func (f *flowParser) value(inFlow bool) (*tree.Node, error) {
+	f.skipSpaces()
+
+	tag := ""
+	if f.i < len(f.s) && f.s[f.i] == '!' {
+		start := f.i + 1
+		for f.i < len(f.s) && f.s[f.i] != ' ' && !(inFlow && strings.ContainsRune(",]}", rune(f.s[f.i]))) {
+			f.i++
+		}
+		tag = f.s[start:f.i]
+		f.skipSpaces()
+	}
+
+	n, err := f.untagged(inFlow)
+	if err != nil {
+		return nil, err
+	}
+	n.Tag = tag
+	n.Line = f.line
+	return n, nil
+}
+
+func (f *flowParser) untagged(inFlow bool) (*tree.Node, error) {
+	if f.atEnd(inFlow) {
+		return &tree.Node{Kind: tree.ScalarNode}, nil
+	}
+
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		n := tree.NewSequence()
+		for {
+			f.skipSpaces()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				return n, nil
+			}
+			item, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			n.Items = append(n.Items, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+
+	case '{':
+		f.i++
+		n := tree.NewMapping()
+		for {
+			f.skipSpaces()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				return n, nil
+			}
+			key, err := f.key()
+			if err != nil {
+				return nil, err
+			}
+			value, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			n.Append(key, value)
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+
+	case '"', '\'':
+		s, err := f.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return tree.NewString(s), nil
+
+	case '&', '*', '|', '>':
+		return nil, f.errorf("anchors, aliases and block scalars are not supported")
+	}
+
+	start := f.i
+	for !f.atEnd(inFlow) {
+		f.i++
+	}
+	return tree.NewScalar(strings.TrimRight(f.s[start:f.i], " ")), nil
+}
+
+// separator reads the comma between the entries of a flow collection. It
+// leaves the closing bracket to be read by the caller.
+func (f *flowParser) separator(close byte) error {
+	f.skipSpaces()
+	switch {
+	case f.i >= len(f.s):
+		return f.errorf("missing %q", close)
+	case f.s[f.i] == ',':
+		f.i++
+		return nil
+	case f.s[f.i] == close:
+		return nil
+	}
+	return f.errorf("expected ',' or %q, got %q", close, snippet(f.s[f.i:]))
+}
+
+func (f *flowParser) key() (string, error) {
+	var key string
+	if f.s[f.i] == '"' || f.s[f.i] == '\'' {
+		var err error
+		key, err = f.quoted()
+		if err != nil {
+			return "", err
+		}
+		f.skipSpaces()
+	} else {
+		start := f.i
+		for f.i < len(f.s) && !strings.ContainsRune(":,]}", rune(f.s[f.i])) {
+			f.i++
+		}
+		key = strings.TrimRight(f.s[start:f.i], " ")
+	}
+
+	if f.i >= len(f.s) || f.s[f.i] != ':' {
+		return "", f.errorf("expected ':' after key %q", key)
+	}
+	f.i++
+	return key, nil
+}
+
+func (f *flowParser) quoted() (string, error) {
+	quote := f.s[f.i]
+	f.i++
+
+	var sb strings.Builder
+	for f.i < len(f.s) {
+		c := f.s[f.i]
+		f.i++
+
+		switch {
+		case c == quote && quote == '\'' && f.i < len(f.s) && f.s[f.i] == '\'':
+			sb.WriteByte('\'')
+			f.i++
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && quote == '"':
+			err := f.escape(&sb)
+			if err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", f.errorf("unterminated quoted scalar")
+}
+
+func (f *flowParser) escape(sb *strings.Builder) error {
+	if f.i >= len(f.s) {
+		return f.errorf("unterminated escape sequence")
+	}
+	c := f.s[f.i]
+	f.i++
+
+	simple := map[byte]string{
+		'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+		'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0",
+		'L': "\u2028", 'P': "\u2029",
+	}
+	if s, ok := simple[c]; ok {
+		sb.WriteString(s)
+		return nil
+	}
+
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if digits == 0 || f.i+digits > len(f.s) {
+		return f.errorf("invalid escape sequence \\%c", c)
+	}
+	code, err := strconv.ParseUint(f.s[f.i:f.i+digits], 16, 32)
+	if err != nil {
+		return f.errorf("invalid escape sequence \\%c%s", c, f.s[f.i:f.i+digits])
+	}
+	f.i += digits
+	sb.WriteRune(rune(code))
+	return nil
+}
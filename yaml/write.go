@@ -0,0 +1,201 @@
+package yaml
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"revision-go/internal/tree"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// collections of scalars up to this width are written in flow style
+const maxFlowWidth = 72
+
+// words that YAML 1.1 reads as booleans
+var booleanWords = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"n": true, "N": true, "no": true, "No": true, "NO": true,
+	"on": true, "On": true, "ON": true, "off": true, "Off": true, "OFF": true,
+}
+
+// isPlain reports whether s can be written as a plain scalar that is read
+// back as the same string, by this package and by other YAML readers.
+func isPlain(s string, inFlow bool) bool {
+	if s == "" || tree.Resolve(s) != "str" || booleanWords[s] {
+		return false
+	}
+	if strings.HasPrefix(s, "---") || strings.HasPrefix(s, "...") {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}&*!|>%@`+.0123456789 ") || strings.HasSuffix(s, " ") {
+		return false
+	}
+	if strings.ContainsAny(s, `#'"`) || strings.Contains(s, ": ") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	if inFlow && strings.ContainsAny(s, ",[]{}:") {
+		return false
+	}
+
+	for _, r := range s {
+		if r != ' ' && !unicode.IsPrint(r) || r == utf8.RuneError {
+			return false
+		}
+	}
+	return true
+}
+
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&sb, `\x%02X`, r)
+		case r != ' ' && !unicode.IsPrint(r):
+			fmt.Fprintf(&sb, `\u%04X`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func scalarText(s string, isString bool, inFlow bool) string {
+	if !isString {
+		if s == "" {
+			return "null"
+		}
+		return s
+	}
+	if isPlain(s, inFlow) {
+		return s
+	}
+	return quote(s)
+}
+
+func tagPrefix(n *tree.Node) string {
+	if n.Tag == "" {
+		return ""
+	}
+	return "!" + n.Tag + " "
+}
+
+// flow returns n in flow style, or false if n is a collection that is to be
+// written in block style.
+func flow(n *tree.Node, inFlow bool) (string, bool) {
+	if n.Kind == tree.ScalarNode {
+		return tagPrefix(n) + scalarText(n.Value, n.String, inFlow), true
+	}
+
+	open, close := "[", "]"
+	if n.Kind == tree.MappingNode {
+		open, close = "{", "}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tagPrefix(n) + open)
+	for i, item := range n.Items {
+		if item.Kind != tree.ScalarNode {
+			return "", false
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if n.Kind == tree.MappingNode {
+			sb.WriteString(scalarText(n.Keys[i], true, true) + ": ")
+		}
+		text, _ := flow(item, true)
+		sb.WriteString(text)
+		if sb.Len() > maxFlowWidth {
+			return "", false
+		}
+	}
+	sb.WriteString(close)
+
+	return sb.String(), true
+}
+
+type emitter struct {
+	w *bufio.Writer
+}
+
+func (e *emitter) indent(indent int) {
+	e.w.WriteString(strings.Repeat(" ", indent))
+}
+
+// value writes a node after "key:" or "-", followed by a newline.
+func (e *emitter) value(n *tree.Node, indent int) {
+	if text, ok := flow(n, false); ok {
+		e.w.WriteString(" " + text + "\n")
+		return
+	}
+
+	if n.Tag != "" {
+		e.w.WriteString(" !" + n.Tag)
+	}
+	e.w.WriteByte('\n')
+	e.block(n, indent+2, false)
+}
+
+// block writes a mapping or sequence in block style. With dash, the first
+// line continues a sequence entry.
+func (e *emitter) block(n *tree.Node, indent int, dash bool) {
+	for i, item := range n.Items {
+		if i == 0 && dash {
+			e.w.WriteString("- ")
+		} else {
+			e.indent(indent)
+		}
+
+		if n.Kind == tree.MappingNode {
+			e.w.WriteString(scalarText(n.Keys[i], true, false) + ":")
+			e.value(item, indent)
+			continue
+		}
+
+		_, isFlow := flow(item, false)
+		if item.Kind == tree.MappingNode && item.Tag == "" && !isFlow {
+			e.block(item, indent+2, true)
+			continue
+		}
+		e.w.WriteString("-")
+		e.value(item, indent)
+	}
+}
+
+// Write writes n as a YAML document, preceded by comment lines if comment
+// is not empty.
+func Write(w io.Writer, n *tree.Node, comment string) error {
+	e := &emitter{w: bufio.NewWriter(w)}
+
+	if comment != "" {
+		for _, line := range strings.Split(comment, "\n") {
+			e.w.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+	}
+
+	if text, ok := flow(n, false); ok || n.Tag != "" {
+		if !ok {
+			return fmt.Errorf("a tagged collection cannot be written as a document")
+		}
+		e.w.WriteString(text + "\n")
+	} else {
+		e.block(n, 0, false)
+	}
+
+	return e.w.Flush()
+}